@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// roleAttribute is the certificate attribute issued by the CA carrying the caller's roles;
+// several roles may be given as a comma separated list
+const roleAttribute = "role"
+
+// Permissions checked by contract methods
+const (
+	permAdminister  = "administer"   // admin registry, approvals and ledger setup
+	permParticipate = "participate"  // participant registration, token and mint requests, sending transfers
+	permManageToken = "manage_token" // token owner handling of customers and received transfers
+	permTransact    = "transact"     // customer registration, mints and wallet
+	permReadHistory = "read_history" // transfer history queries
+	permReadPublic  = "read_public"  // token catalogue and participant lookups
+)
+
+// rolePermissions maps role attribute values to the permissions they grant
+var rolePermissions = map[string][]string{
+	"admin":       {permAdminister, permReadHistory, permReadPublic},
+	"token_owner": {permParticipate, permManageToken, permReadHistory, permReadPublic},
+	"customer":    {permTransact, permReadHistory, permReadPublic},
+	"auditor":     {permReadHistory, permReadPublic},
+}
+
+// requirePermission checks that one of the caller's role attributes grants perm. Until stored
+// amounts are migrated every transaction is refused, see MigrateAmounts.
+func (s *SmartContract) requirePermission(ctx contractapi.TransactionContextInterface, perm string) error {
+	if err := s.checkPermission(ctx, perm); err != nil {
+		return err
+	}
+	return s.requireAmountsMigrated(ctx)
+}
+
+// checkPermission is requirePermission for the transactions that bring a ledger up to date
+func (s *SmartContract) checkPermission(ctx contractapi.TransactionContextInterface, perm string) error {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("access denied: caller has no %s attribute", roleAttribute)
+	}
+
+	for _, role := range strings.Split(value, ",") {
+		for _, p := range rolePermissions[strings.TrimSpace(role)] {
+			if p == perm {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("access denied: %s permission required", perm)
+}
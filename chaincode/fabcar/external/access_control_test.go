@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission_Roles(t *testing.T) {
+	tests := []struct {
+		name        string
+		attrs       map[string]string
+		permission  string
+		expectError bool
+	}{
+		{name: "admin administers", attrs: map[string]string{"role": "admin"}, permission: permAdminister},
+		{name: "token owner participates", attrs: map[string]string{"role": "token_owner"}, permission: permParticipate},
+		{name: "customer transacts", attrs: map[string]string{"role": "customer"}, permission: permTransact},
+		{name: "auditor reads history", attrs: map[string]string{"role": "auditor"}, permission: permReadHistory},
+		{name: "customer reads history", attrs: map[string]string{"role": "customer"}, permission: permReadHistory},
+		{name: "multiple roles", attrs: map[string]string{"role": "customer, auditor"}, permission: permReadHistory},
+		{name: "customer cannot administer", attrs: map[string]string{"role": "customer"}, permission: permAdminister, expectError: true},
+		{name: "auditor cannot participate", attrs: map[string]string{"role": "auditor"}, permission: permParticipate, expectError: true},
+		{name: "unknown role", attrs: map[string]string{"role": "superuser"}, permission: permReadPublic, expectError: true},
+		{name: "no role attribute", attrs: map[string]string{"department": "finance"}, permission: permReadPublic, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTestHelper()
+			h.SetIdentityWithAttributes("user", "Org2MSP", tt.attrs)
+
+			err := h.Contract.requirePermission(h.Ctx, tt.permission)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "access denied")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestContractMethodsEnforceRoles(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	// Customers cannot act as participants
+	h.SetIdentity("test-client-id", "Org1MSP", "customer")
+	_, err = h.CreateParticipant("Mallory", "pass", "USA")
+	assert.Error(t, err)
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+	_, err = h.Contract.GetWalletInfo(h.Ctx, netAddr, "", "pass123")
+	assert.Error(t, err)
+
+	// Registered admins lose admin rights without the admin role attribute
+	h.SetIdentity("test-client-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.GetPendingTokenRequests(h.Ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), permAdminister)
+
+	// Identities without any role attribute are refused outright
+	h.SetIdentity("test-client-id", "Org1MSP", "")
+	_, err = h.Contract.ParticipantExists(h.Ctx, netAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no role attribute")
+
+	// Auditors may look up participants but not request tokens
+	h.SetIdentity("auditor-id", "Org2MSP", "auditor")
+	exists, err := h.Contract.ParticipantExists(h.Ctx, netAddr)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+}
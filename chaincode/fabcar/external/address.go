@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// deriveNetworkAddress derives a new participant address from the caller's certificate and the
+// transaction ID. Every endorser computes the same value, but it cannot be guessed from a name.
+func deriveNetworkAddress(ctx contractapi.TransactionContextInterface) (string, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil || cert == nil {
+		return "", fmt.Errorf("unable to read caller certificate")
+	}
+	return addressDigest(cert.Raw, []byte(ctx.GetStub().GetTxID())), nil
+}
+
+func addressDigest(parts ...[]byte) string {
+	h := sha256.New()
+	h.Write([]byte("netaddr"))
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// legacyNetworkAddress is the name-derived address used before addresses were bound to certificates
+func legacyNetworkAddress(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(hash[:])
+}
+
+// AddressMigration reports one batch of MigrateNetworkAddresses
+type AddressMigration struct {
+	Moved    map[string]string `json:"moved"`    // old to new address
+	Bookmark string            `json:"bookmark"` // startKey of the next batch, empty once the ledger is done
+}
+
+// MigrateNetworkAddresses re-keys participants still living at a name-derived address, together
+// with their identity index entries and every record that refers to them by address. The new address
+// is derived from the participant's bound identity and the transaction ID. Participants are taken
+// pageSize records from startKey at a time; run it again from the returned bookmark until that is empty.
+func (s *SmartContract) MigrateNetworkAddresses(ctx contractapi.TransactionContextInterface, startKey string, pageSize int) (*AddressMigration, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	records, bookmark, err := migrationPage(ctx, startKey, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AddressMigration{Moved: make(map[string]string), Bookmark: bookmark}
+	for _, kv := range records {
+		var p Participant
+		if json.Unmarshal(kv.Value, &p) != nil || p.NetworkAddress != kv.Key {
+			continue
+		}
+		if err := s.loadParticipantPII(ctx, &p); err != nil {
+			return nil, err
+		}
+		if p.NetworkAddress != legacyNetworkAddress(p.Name) {
+			continue
+		}
+		newAddr := addressDigest([]byte(strings.Join(p.boundClientIDs(), "\x00")), []byte(ctx.GetStub().GetTxID()), []byte(kv.Key))
+		if err := s.moveParticipant(ctx, &p, newAddr); err != nil {
+			return nil, err
+		}
+		res.Moved[kv.Key] = newAddr
+	}
+	if len(res.Moved) == 0 {
+		return res, nil
+	}
+
+	// Records referring to the moved participants may sit anywhere in the ledger. Collect them
+	// first so the rewrites below do not depend on iterator behaviour.
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var related []*keyValue
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(kv.Key, "tokenrequest_"), strings.HasPrefix(kv.Key, tokenRequestHistoryPrefix),
+			strings.HasPrefix(kv.Key, "mintrequest_"), strings.HasPrefix(kv.Key, "token_"),
+			strings.HasPrefix(kv.Key, rebindRequestKeyPrefix), strings.HasPrefix(kv.Key, mintLimitKeyPrefix),
+			strings.HasPrefix(kv.Key, ownershipTransferKeyPrefix), strings.HasPrefix(kv.Key, revocationKeyPrefix):
+			related = append(related, &keyValue{kv.Key, kv.Value})
+		}
+	}
+	for _, kv := range related {
+		if err := s.rekeyReferences(ctx, kv, res.Moved); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// movedAddress returns the new address of addr if it was moved, else addr itself
+func movedAddress(moved map[string]string, addr string) string {
+	if moved[addr] != "" {
+		return moved[addr]
+	}
+	return addr
+}
+
+type keyValue struct {
+	Key   string
+	Value []byte
+}
+
+// moveParticipant stores p under newAddr and removes the record, private details and index
+// entries of its old address
+func (s *SmartContract) moveParticipant(ctx contractapi.TransactionContextInterface, p *Participant, newAddr string) error {
+	oldAddr := p.NetworkAddress
+	p.NetworkAddress = newAddr
+	if err := s.putParticipant(ctx, p, true); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(oldAddr); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelPrivateData(piiCollection, oldAddr); err != nil {
+		return err
+	}
+	for _, id := range p.boundClientIDs() {
+		if err := ctx.GetStub().PutState(participantIDKey(id), []byte(newAddr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rekeyReferences rewrites a token, current or past token request, mint request, mint limit,
+// rebind request, token ownership transfer or revocation that refers to a moved participant
+func (s *SmartContract) rekeyReferences(ctx contractapi.TransactionContextInterface, kv *keyValue, moved map[string]string) error {
+	var newKey string
+	var updated interface{}
+	switch {
+	case strings.HasPrefix(kv.Key, "tokenrequest_"):
+		var r TokenRequest
+		if json.Unmarshal(kv.Value, &r) != nil || moved[r.NetworkAddr] == "" {
+			return nil
+		}
+		if r.WaitlistedAt != "" {
+			if err := s.moveWaitlistEntry(ctx, r.WaitlistedAt, r.NetworkAddr, moved[r.NetworkAddr]); err != nil {
+				return err
+			}
+		}
+		r.NetworkAddr = moved[r.NetworkAddr]
+		r.RequestID = "tokenrequest_" + r.NetworkAddr
+		if err := r.sealPincode(ctx); err != nil {
+			return err
+		}
+		newKey, updated = r.RequestID, r
+	case strings.HasPrefix(kv.Key, tokenRequestHistoryPrefix):
+		var r TokenRequest
+		if json.Unmarshal(kv.Value, &r) != nil || moved[r.NetworkAddr] == "" {
+			return nil
+		}
+		r.NetworkAddr = moved[r.NetworkAddr]
+		r.RequestID = tokenRequestHistoryKey(r.NetworkAddr, r.Sequence)
+		if err := r.sealPincode(ctx); err != nil {
+			return err
+		}
+		newKey, updated = r.RequestID, r
+	case strings.HasPrefix(kv.Key, "mintrequest_"):
+		var r MintRequest
+		if json.Unmarshal(kv.Value, &r) != nil || moved[r.RequestedBy] == "" {
+			return nil
+		}
+		// Request IDs are references handed out to clients and stay as they are
+		r.RequestedBy = moved[r.RequestedBy]
+		newKey, updated = kv.Key, r
+	case strings.HasPrefix(kv.Key, mintLimitKeyPrefix):
+		var l MintLimit
+		if json.Unmarshal(kv.Value, &l) != nil || (moved[l.NetworkAddress] == "" && moved[l.SetBy] == "") {
+			return nil
+		}
+		l.SetBy = movedAddress(moved, l.SetBy)
+		newKey = kv.Key
+		// Limits on customers are keyed by the customer's address, which does not move
+		if strings.HasPrefix(kv.Key, mintLimitKeyPrefix+mintRequestKeyPrefix) {
+			l.NetworkAddress = movedAddress(moved, l.NetworkAddress)
+			newKey = mintLimitKey(mintRequestKeyPrefix, l.TokenID, l.NetworkAddress)
+		}
+		updated = l
+	case strings.HasPrefix(kv.Key, ownershipTransferKeyPrefix):
+		var ot TokenOwnershipTransfer
+		if json.Unmarshal(kv.Value, &ot) != nil || (moved[ot.FromOwner] == "" && moved[ot.ToOwner] == "") {
+			return nil
+		}
+		ot.FromOwner = movedAddress(moved, ot.FromOwner)
+		ot.ToOwner = movedAddress(moved, ot.ToOwner)
+		newKey, updated = kv.Key, ot
+	case strings.HasPrefix(kv.Key, revocationKeyPrefix):
+		var r TokenRevocation
+		if json.Unmarshal(kv.Value, &r) != nil || moved[r.PreviousOwner] == "" {
+			return nil
+		}
+		r.PreviousOwner = moved[r.PreviousOwner]
+		newKey, updated = kv.Key, r
+	case strings.HasPrefix(kv.Key, rebindRequestKeyPrefix):
+		var r IdentityRebindRequest
+		if json.Unmarshal(kv.Value, &r) != nil || moved[r.NetworkAddress] == "" {
+			return nil
+		}
+		r.NetworkAddress = moved[r.NetworkAddress]
+		r.RequestID = rebindRequestKey(r.NetworkAddress)
+		newKey, updated = r.RequestID, r
+	default:
+		var t Token
+		if json.Unmarshal(kv.Value, &t) != nil || moved[t.Owner] == "" {
+			return nil
+		}
+		t.Owner = moved[t.Owner]
+		newKey, updated = kv.Key, t
+	}
+
+	b, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	if newKey != kv.Key {
+		if err := ctx.GetStub().DelState(kv.Key); err != nil {
+			return err
+		}
+	}
+	return ctx.GetStub().PutState(newKey, b)
+}
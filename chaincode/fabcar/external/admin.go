@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// bootstrapAdminMSP is the only MSP allowed to seed an empty admin registry
+const bootstrapAdminMSP = "Org1MSP"
+
+const adminKeyPrefix = "admin_"
+
+// Admin is an entry in the on-ledger admin registry, keyed by client identity
+type Admin struct {
+	ClientID string `json:"client_id"`
+	MSPID    string `json:"msp_id"`
+	AddedBy  string `json:"added_by"`
+}
+
+func adminKey(clientID string) string {
+	return adminKeyPrefix + clientID
+}
+
+// initAdminRegistry registers the caller as first admin when the registry is empty,
+// otherwise it only lets existing admins through
+func (s *SmartContract) initAdminRegistry(ctx contractapi.TransactionContextInterface) error {
+	admins, err := s.getAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if len(admins) > 0 {
+		return s.VerifyAdmin(ctx)
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return err
+	}
+	if msp != bootstrapAdminMSP {
+		return fmt.Errorf("access denied: admin registry must be bootstrapped by %s", bootstrapAdminMSP)
+	}
+
+	a := Admin{ClientID: clientID, MSPID: msp, AddedBy: clientID}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(adminKey(clientID), b)
+}
+
+// VerifyAdmin restricts to identities holding the admin role and registered in the admin registry
+func (s *SmartContract) VerifyAdmin(ctx contractapi.TransactionContextInterface) error {
+	if err := s.verifyRegisteredAdmin(ctx); err != nil {
+		return err
+	}
+	return s.requireAmountsMigrated(ctx)
+}
+
+// verifyRegisteredAdmin is VerifyAdmin for the transactions that bring a ledger up to date
+func (s *SmartContract) verifyRegisteredAdmin(ctx contractapi.TransactionContextInterface) error {
+	if err := s.checkPermission(ctx, permAdminister); err != nil {
+		return err
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return err
+	}
+
+	b, err := ctx.GetStub().GetState(adminKey(clientID))
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return fmt.Errorf("access denied: admin only")
+	}
+	var a Admin
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+	if a.MSPID != msp {
+		return fmt.Errorf("access denied: admin only")
+	}
+	return nil
+}
+
+// AddAdmin registers another client identity as admin. Once a mint approval policy needs
+// several admins, the same number of admins must call AddAdmin with the same arguments before
+// the admin is registered; pending calls are listed by GetAdminProposals.
+func (s *SmartContract) AddAdmin(ctx contractapi.TransactionContextInterface, clientID, mspID string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	if clientID == "" || mspID == "" {
+		return fmt.Errorf("client id and msp id are required")
+	}
+
+	existing, err := ctx.GetStub().GetState(adminKey(clientID))
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("admin already registered")
+	}
+	done, err := s.proposeAdminAction(ctx, adminActionAdd, clientID, []string{mspID})
+	if err != nil || !done {
+		return err
+	}
+
+	callerID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	a := Admin{ClientID: clientID, MSPID: mspID, AddedBy: callerID}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(adminKey(clientID), b)
+}
+
+// RemoveAdmin removes a client identity from the admin registry, once a quorum of admins has
+// called it, see AddAdmin. The registry never shrinks below the quorum or the last admin.
+func (s *SmartContract) RemoveAdmin(ctx contractapi.TransactionContextInterface, clientID string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetState(adminKey(clientID))
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("admin not found")
+	}
+
+	admins, err := s.getAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if len(admins) <= 1 {
+		return fmt.Errorf("cannot remove the last admin")
+	}
+	quorum, err := s.adminQuorum(ctx)
+	if err != nil {
+		return err
+	}
+	if len(admins)-1 < quorum {
+		return fmt.Errorf("cannot remove an admin: %d admins must remain to approve mints", quorum)
+	}
+	done, err := s.proposeAdminAction(ctx, adminActionRemove, clientID, nil)
+	if err != nil || !done {
+		return err
+	}
+	return ctx.GetStub().DelState(adminKey(clientID))
+}
+
+// ListAdmins returns every registered admin
+func (s *SmartContract) ListAdmins(ctx contractapi.TransactionContextInterface) ([]Admin, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.getAdmins(ctx)
+}
+
+// getAdmins reads the admin registry without access checks
+func (s *SmartContract) getAdmins(ctx contractapi.TransactionContextInterface) ([]Admin, error) {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var admins []Admin
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, adminKeyPrefix) {
+			var a Admin
+			if err := json.Unmarshal(kv.Value, &a); err == nil {
+				admins = append(admins, a)
+			}
+		}
+	}
+	return admins, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitLedgerBootstrapsAdmin(t *testing.T) {
+	h := NewTestHelper()
+
+	err := h.InitLedgerWithTokens()
+	assert.NoError(t, err)
+
+	admins, err := h.Contract.ListAdmins(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, admins, 1)
+	assert.Equal(t, "test-client-id", admins[0].ClientID)
+	assert.Equal(t, "Org1MSP", admins[0].MSPID)
+
+	// Re-running InitLedger as a non-admin is refused once the registry exists
+	h.SetIdentity("intruder", "Org1MSP", "admin")
+	err = h.InitLedgerWithTokens()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin only")
+}
+
+func TestInitLedgerBootstrapRequiresOrg1(t *testing.T) {
+	h := NewTestHelper()
+	h.SetIdentity("org2-admin", "Org2MSP", "admin")
+
+	err := h.InitLedgerWithTokens()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bootstrapped")
+}
+
+func TestAdminRegistryGatesAdminFunctions(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	// Another Org1 identity is no longer an admin just because of its MSP
+	h.SetIdentity("org1-user", "Org1MSP", "admin")
+	_, err := h.Contract.GetPendingTokenRequests(h.Ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin only")
+	err = h.Contract.ApproveMintRequest(h.Ctx, "mintrequest_token_1_addr")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin only")
+
+	// An identity from another org can be made admin
+	h.SetAsAdmin()
+	err = h.Contract.AddAdmin(h.Ctx, "org2-admin", "Org2MSP")
+	assert.NoError(t, err)
+	err = h.Contract.AddAdmin(h.Ctx, "org2-admin", "Org2MSP")
+	assert.Error(t, err)
+
+	h.SetIdentity("org2-admin", "Org2MSP", "admin")
+	_, err = h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.NoError(t, err)
+
+	// Same client ID presented under a different MSP is rejected
+	h.SetIdentity("org2-admin", "Org1MSP", "admin")
+	_, err = h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.Error(t, err)
+}
+
+func TestRemoveAdmin(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	err := h.Contract.RemoveAdmin(h.Ctx, "test-client-id")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "last admin")
+
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "org2-admin", "Org2MSP"))
+	assert.NoError(t, h.Contract.RemoveAdmin(h.Ctx, "org2-admin"))
+
+	err = h.Contract.RemoveAdmin(h.Ctx, "org2-admin")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "admin not found")
+
+	h.SetIdentity("org2-admin", "Org2MSP", "admin")
+	_, err = h.Contract.ListAdmins(h.Ctx)
+	assert.Error(t, err)
+}
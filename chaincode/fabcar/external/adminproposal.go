@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const adminProposalKeyPrefix = "adminproposal_"
+
+// Admin actions that need a quorum of admins, see proposeAdminAction
+const (
+	adminActionAdd        = "ADD_ADMIN"
+	adminActionRemove     = "REMOVE_ADMIN"
+	adminActionMintPolicy = "MINT_POLICY"
+)
+
+// AdminProposal is an admin action waiting for more admins to call it with the same arguments
+type AdminProposal struct {
+	ProposalID string         `json:"proposal_id"`
+	Action     string         `json:"action"` // ADD_ADMIN, REMOVE_ADMIN or MINT_POLICY
+	Target     string         `json:"target"` // client id of the admin, or token id
+	Args       []string       `json:"args,omitempty"`
+	ProposedAt string         `json:"proposed_at"`
+	Approvals  []MintApproval `json:"approvals"`
+}
+
+func adminProposalKey(action, target string) string {
+	return adminProposalKeyPrefix + action + "_" + target
+}
+
+// adminQuorum is how many admins must agree to change the admin registry or a mint approval
+// policy: the highest approvals any token's mint policy requires, so no single admin can undo it
+func (s *SmartContract) adminQuorum(ctx contractapi.TransactionContextInterface) (int, error) {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	quorum := 1
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(kv.Key, tokenIDPrefix) {
+			continue
+		}
+		var t Token
+		if json.Unmarshal(kv.Value, &t) != nil {
+			continue
+		}
+		if t.MintApprovalsRequired > quorum {
+			quorum = t.MintApprovalsRequired
+		}
+	}
+	return quorum, nil
+}
+
+// proposeAdminAction records the calling admin's approval of action on target with args and
+// reports whether the admin quorum is now reached, in which case the caller carries the action
+// out. Approvals from admins removed since do not count.
+func (s *SmartContract) proposeAdminAction(ctx contractapi.TransactionContextInterface, action, target string, args []string) (bool, error) {
+	quorum, err := s.adminQuorum(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	key := adminProposalKey(action, target)
+	b, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, err
+	}
+	var p AdminProposal
+	if b != nil {
+		if err := json.Unmarshal(b, &p); err != nil {
+			return false, err
+		}
+		if strings.Join(p.Args, "\x00") != strings.Join(args, "\x00") {
+			return false, fmt.Errorf("a different %s proposal for %s is pending; cancel it first", action, target)
+		}
+	} else {
+		proposedAt, err := txTime(ctx)
+		if err != nil {
+			return false, err
+		}
+		p = AdminProposal{ProposalID: key, Action: action, Target: target, Args: args, ProposedAt: proposedAt}
+	}
+
+	if p.Approvals, err = s.addApproval(ctx, p.Approvals, "admin proposal"); err != nil {
+		return false, err
+	}
+	count, err := s.countApprovals(ctx, p.Approvals)
+	if err != nil {
+		return false, err
+	}
+	if count >= quorum {
+		if b == nil {
+			return true, nil
+		}
+		return true, ctx.GetStub().DelState(key)
+	}
+
+	pb, err := json.Marshal(p)
+	if err != nil {
+		return false, err
+	}
+	return false, ctx.GetStub().PutState(key, pb)
+}
+
+// GetAdminProposals lists the admin actions still waiting for a quorum (admin)
+func (s *SmartContract) GetAdminProposals(ctx contractapi.TransactionContextInterface) ([]AdminProposal, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	proposals := []AdminProposal{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(kv.Key, adminProposalKeyPrefix) {
+			continue
+		}
+		var p AdminProposal
+		if json.Unmarshal(kv.Value, &p) != nil {
+			continue
+		}
+		proposals = append(proposals, p)
+	}
+	return proposals, nil
+}
+
+// CancelAdminProposal drops a pending admin action together with its approvals (admin)
+func (s *SmartContract) CancelAdminProposal(ctx contractapi.TransactionContextInterface, proposalID string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	b, err := ctx.GetStub().GetState(proposalID)
+	if err != nil {
+		return err
+	}
+	if b == nil || !strings.HasPrefix(proposalID, adminProposalKeyPrefix) {
+		return fmt.Errorf("admin proposal not found")
+	}
+	return ctx.GetStub().DelState(proposalID)
+}
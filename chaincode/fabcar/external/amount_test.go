@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	a, err := parseAmount("12.5", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("1250"), a)
+	assert.Equal(t, "12.50", a.format(2))
+	assert.Equal(t, "0.05", Amount("5").format(2))
+
+	for _, bad := range []string{"", "-1", "+1", "1e3", "NaN", "Inf", "1.", ".5", "1.234", "0x10", " 1"} {
+		_, err := parseAmount(bad, 2)
+		assert.Error(t, err, bad)
+	}
+	_, err = parseAmount("1"+strings.Repeat("0", 78), 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "overflow")
+	_, err = parsePositiveAmount("0.00", 2)
+	assert.Error(t, err)
+
+	_, err = Amount("5").sub(Amount("6"))
+	assert.Error(t, err)
+}
+
+func TestAmountJSON(t *testing.T) {
+	var mr MintRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"42"}`), &mr))
+	assert.Equal(t, Amount("42"), mr.Amount)
+	err := json.Unmarshal([]byte(`{"amount":42}`), &mr)
+	assert.Error(t, err, "legacy numbers count whole coins and need MigrateAmounts")
+	assert.Contains(t, err.Error(), "run MigrateAmounts")
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":-3}`), &mr))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"1.5"}`), &mr))
+
+	b, err := json.Marshal(Token{TokenID: "token_1"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"minted":"0"`)
+}
+
+func TestTokenDecimals(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "ALC", 2, "", "", ""))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenMetadata(h.Ctx, tokenID))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "1.005")
+	assert.Error(t, err)
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10.25")
+	assert.NoError(t, err)
+	mr, err := h.GetMintRequest(mintReqID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("1025"), mr.Amount)
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mr.RequestID))
+
+	// Decimals are fixed once amounts exist in the token's minor units
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	err = h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "ALC", 4, "", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "decimals cannot change")
+	wallet, err := h.Contract.GetWalletInfo(h.Ctx, netAddr, tokenID, "pass123")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, wallet["decimals"])
+	assert.Equal(t, Amount("1025"), wallet["mintedCoins"])
+}
+
+func TestTokenDecimalsFixedByRecordedAmounts(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	// A pending mint request holds an amount in the current minor units
+	_, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+	err = h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "ALC", 2, "", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "amounts are recorded")
+
+	// So does a mint limit
+	bobAddr, bobToken := setupTokenOwner(t, h, "bob-id", "Bob")
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetParticipantMintLimits(h.Ctx, bobAddr, bobToken, "5", "0"))
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	err = h.Contract.UpdateTokenMetadata(h.Ctx, bobToken, "Bob Coin", "BOB", 2, "", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "amounts are recorded")
+
+	// Records of other tokens do not count
+	_, carolToken := setupTokenOwner(t, h, "carol-id", "Carol")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, carolToken, "Carol Coin", "CRL", 2, "", "", ""))
+}
+
+func TestMigrateAmounts(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	h.Stub.State["token_1"] = []byte(`{"token_id":"token_1","owner":"addr1","minted":7,"metadata":{"name":"One","symbol":"ONE","decimals":2}}`)
+	h.Stub.State["token_2"] = []byte(`{"token_id":"token_2","owner":"addr2","minted":-4}`)
+	h.Stub.State["mintrequest_token_1_addr1"] = []byte(`{"request_id":"mintrequest_token_1_addr1","token_id":"token_1","amount":3,"approved":false}`)
+	delete(h.Stub.State, amountsMigratedKey)
+
+	// Nothing runs on the upgraded ledger until its amounts are migrated
+	_, err := h.Contract.ParticipantExists(h.Ctx, "addr1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "run MigrateAmounts")
+	_, err = h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.Error(t, err)
+
+	h.SetAsNonAdmin()
+	_, err = h.Contract.MigrateAmounts(h.Ctx, "", 1000)
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	res, err := h.Contract.MigrateAmounts(h.Ctx, "", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Migrated)
+	assert.Equal(t, []string{"token_2"}, res.Skipped)
+
+	token, err := h.GetToken("token_1")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("700"), token.Minted)
+	assert.Equal(t, "addr1", token.Owner)
+	mr, err := h.GetMintRequest("mintrequest_token_1_addr1")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("300"), mr.Amount)
+
+	// The skipped token keeps the ledger closed until it is repaired
+	_, err = h.Contract.ParticipantExists(h.Ctx, "addr1")
+	assert.Error(t, err)
+	h.Stub.State["token_2"] = []byte(`{"token_id":"token_2","owner":"addr2","minted":4}`)
+
+	res, err = h.Contract.MigrateAmounts(h.Ctx, "", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Migrated)
+	assert.Empty(t, res.Skipped)
+	_, err = h.Contract.ParticipantExists(h.Ctx, "addr1")
+	assert.NoError(t, err)
+
+	// A further run finds nothing left to convert
+	res, err = h.Contract.MigrateAmounts(h.Ctx, "", 1000)
+	assert.NoError(t, err)
+	assert.Zero(t, res.Migrated)
+}
+
+func TestMigrateAmountsInBatches(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	h.Stub.State["token_1"] = []byte(`{"token_id":"token_1","owner":"addr1","minted":-4}`)
+	h.Stub.State["token_2"] = []byte(`{"token_id":"token_2","owner":"addr2","minted":4,"metadata":{"name":"Two","symbol":"TWO","decimals":1}}`)
+	h.Stub.State["mintrequest_token_2_addr2"] = []byte(`{"request_id":"mintrequest_token_2_addr2","token_id":"token_2","amount":3}`)
+	delete(h.Stub.State, amountsMigratedKey)
+	h.SetAsAdmin()
+
+	// Batches must follow each other; the first one starts at the first key
+	_, err := h.Contract.MigrateAmounts(h.Ctx, "token_2", 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not continue the current pass")
+
+	migrate := func() []string {
+		var skipped []string
+		for startKey := ""; ; {
+			res, err := h.Contract.MigrateAmounts(h.Ctx, startKey, 1)
+			if !assert.NoError(t, err) {
+				return nil
+			}
+			skipped = append(skipped, res.Skipped...)
+			if res.Bookmark == "" {
+				return skipped
+			}
+			startKey = res.Bookmark
+		}
+	}
+
+	// A record skipped in an early batch keeps the ledger closed after the last one
+	assert.Equal(t, []string{"token_1"}, migrate())
+	assert.Nil(t, h.Stub.State[amountsMigratedKey])
+	mr, err := h.GetMintRequest("mintrequest_token_2_addr2")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("30"), mr.Amount)
+
+	h.Stub.State["token_1"] = []byte(`{"token_id":"token_1","owner":"addr1","minted":4}`)
+	assert.Empty(t, migrate())
+	assert.NotNil(t, h.Stub.State[amountsMigratedKey])
+	token, err := h.GetToken("token_2")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("40"), token.Minted)
+}
+
+func TestInitLedgerMarksFreshLedgerMigrated(t *testing.T) {
+	h := NewTestHelper()
+	delete(h.Stub.State, amountsMigratedKey)
+	assert.NoError(t, h.InitLedgerWithTokens())
+	assert.NotNil(t, h.Stub.State[amountsMigratedKey])
+
+	// An existing ledger re-initialized without the marker stays closed
+	delete(h.Stub.State, amountsMigratedKey)
+	assert.Error(t, h.InitLedgerWithTokens())
+	assert.Nil(t, h.Stub.State[amountsMigratedKey])
+}
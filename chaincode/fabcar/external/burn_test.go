@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBurnCoins(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "100")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "100", "0"))
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Bob", "pass456", "UK")
+	assert.NoError(t, err)
+	err = h.Contract.BurnCoins(h.Ctx, tokenID, "10")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.BurnCoins(h.Ctx, tokenID, "0"))
+	err = h.Contract.BurnCoins(h.Ctx, tokenID, "101")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient")
+	assert.NoError(t, h.Contract.BurnCoins(h.Ctx, tokenID, "30"))
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("70"), token.Minted)
+	assert.Equal(t, Amount("30"), token.Burned)
+	assert.Equal(t, Amount("100"), token.TotalMinted)
+
+	// Burned coins still count against the supply cap
+	h.NewTx()
+	mintReqID, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "30")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum supply")
+}
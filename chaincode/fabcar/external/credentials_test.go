@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2SHA256Vectors(t *testing.T) {
+	// Test vectors from RFC 7914, section 11
+	dk := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"+
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783", hex.EncodeToString(dk))
+
+	dk = pbkdf2SHA256([]byte("Password"), []byte("NaCl"), 80000, 64)
+	assert.Equal(t, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"+
+		"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d", hex.EncodeToString(dk))
+}
+
+func TestSubmitRegistrationStoresSaltedCredential(t *testing.T) {
+	h := NewTestHelper()
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Empty(t, p.PasswordHash)
+	assert.NotNil(t, p.Credential)
+	assert.Equal(t, passwordScheme, p.Credential.Scheme)
+	assert.NotEmpty(t, p.Credential.Salt)
+	assert.NotContains(t, string(h.Stub.State[netAddr]), "pass123")
+
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "", "wrong")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "password mismatch")
+}
+
+func TestMigrateCredentials(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	legacy := Participant{Name: "Bob", NetworkAddress: "addrbob", ClientID: "test-client-id", PasswordHash: "bobhash", Country: "UK"}
+	pb, _ := json.Marshal(legacy)
+	h.Stub.State["addrbob"] = pb
+	assert.NoError(t, h.VerifyParticipant("addrbob"))
+
+	// Unmigrated records still authenticate with the verbatim value
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Bob", "addrbob", "bobhash", "UK", "123456", ""))
+
+	page, err := h.Contract.MigrateCredentials(h.Ctx, "", 0)
+	assert.Error(t, err)
+	page, err = h.Contract.MigrateCredentials(h.Ctx, "", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Migrated)
+	assert.Empty(t, page.Bookmark)
+
+	p, err := h.GetParticipant("addrbob")
+	assert.NoError(t, err)
+	assert.Empty(t, p.PasswordHash)
+	assert.NotNil(t, p.Credential)
+
+	// Clients keep sending the same value after migration
+	assert.NoError(t, h.Contract.CancelTokenRequest(h.Ctx, "addrbob"))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Bob", "addrbob", "bobhash", "UK", "123456", ""))
+
+	page, err = h.Contract.MigrateCredentials(h.Ctx, "", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, page.Migrated)
+}
+
+func TestMigrateCredentialsInBatches(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	for _, name := range []string{"bob", "carol", "dave"} {
+		pb, _ := json.Marshal(Participant{Name: name, NetworkAddress: "addr" + name, PasswordHash: name + "hash"})
+		h.Stub.State["addr"+name] = pb
+	}
+
+	// Each batch covers pageSize records and hands back where the next one starts
+	migrated, batches := 0, 0
+	for startKey := ""; ; batches++ {
+		page, err := h.Contract.MigrateCredentials(h.Ctx, startKey, 2)
+		assert.NoError(t, err)
+		migrated += page.Migrated
+		if page.Bookmark == "" {
+			break
+		}
+		assert.Greater(t, page.Bookmark, startKey)
+		startKey = page.Bookmark
+	}
+	assert.Equal(t, 3, migrated)
+	assert.Greater(t, batches, 1)
+	for _, name := range []string{"bob", "carol", "dave"} {
+		p, err := h.GetParticipant("addr" + name)
+		assert.NoError(t, err)
+		assert.NotNil(t, p.Credential, name)
+	}
+}
+
+func TestChangeAndResetPassword(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+
+	err = h.Contract.ChangePassword(h.Ctx, netAddr, "wrong", "newpass")
+	assert.Error(t, err)
+	assert.NoError(t, h.Contract.ChangePassword(h.Ctx, netAddr, "pass123", "newpass"))
+
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "", "pass123")
+	assert.Error(t, err)
+
+	// Admin reset forces a password change before the account can be used again
+	assert.NoError(t, h.Contract.ResetPassword(h.Ctx, netAddr, "temp1"))
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "temp1", "USA", "123456", "")
+	assert.Error(t, err)
+	_, err = h.Contract.GetWalletInfo(h.Ctx, netAddr, "", "temp1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "password was reset")
+
+	assert.NoError(t, h.Contract.ChangePassword(h.Ctx, "", "temp1", "final"))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "final", "USA", "123456", ""))
+
+	// Only admins may reset
+	h.SetIdentity("someone", "Org1MSP", "token_owner")
+	err = h.Contract.ResetPassword(h.Ctx, netAddr, "temp2")
+	assert.Error(t, err)
+}
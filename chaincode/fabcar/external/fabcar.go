@@ -1,8 +1,6 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -14,63 +12,132 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// Participant is the joined view of a participant; Name, Country and the password fields
+// live in the PII collection and are empty on the public record
 type Participant struct {
-	Name           string `json:"name"`
-	NetworkAddress string `json:"network_address"`
-	ClientID       string `json:"client_id"`
-	Approved       bool   `json:"approved"`
-	PasswordHash   string `json:"password_hash"`
-	Country        string `json:"country"`
-	TokenID        string `json:"token_id"`
+	Name            string              `json:"name,omitempty"`
+	NetworkAddress  string              `json:"network_address"`
+	ClientID        string              `json:"client_id,omitempty"` // legacy single identity, see ClientIDs
+	ClientIDs       []string            `json:"client_ids"`          // client identities allowed to act for the participant
+	Approved        bool                `json:"approved"`            // a token request was approved, onboarding state is Status
+	Status          string              `json:"status"`              // REGISTERED, UNDER_REVIEW, VERIFIED, REJECTED, SUSPENDED, CLOSED
+	StatusReason    string              `json:"status_reason,omitempty"`
+	StatusUpdatedBy string              `json:"status_updated_by,omitempty"`
+	PasswordHash    string              `json:"password_hash,omitempty"` // legacy verbatim secret, cleared once Credential is set
+	Credential      *PasswordCredential `json:"credential,omitempty"`
+	Country         string              `json:"country,omitempty"`
+	TokenID         string              `json:"token_id,omitempty"` // legacy single token, see TokenIDs
+	TokenIDs        []string            `json:"token_ids"`          // tokens the participant owns
+	Frozen          bool                `json:"frozen"`
+	FreezeReason    string              `json:"freeze_reason,omitempty"` // reason code, see FreezeParticipant
+	FrozenBy        string              `json:"frozen_by,omitempty"`
+	PrivateDataHash string              `json:"private_data_hash"` // sha256 of the PII collection record
 }
 
 type Token struct {
-	TokenID   string `json:"token_id"`
-	Owner     string `json:"owner"`
-	Available bool   `json:"available"`
-	Minted    int    `json:"minted"`
+	TokenID               string         `json:"token_id"`
+	Owner                 string         `json:"owner"`
+	Available             bool           `json:"available"`
+	Minted                Amount         `json:"minted"`                            // coins held by the owner, not yet issued to customers
+	TotalMinted           Amount         `json:"total_minted"`                      // supply ever minted, see totalMinted
+	MaxSupply             Amount         `json:"max_supply"`                        // cap on TotalMinted, 0 for none
+	MaxMintPerRequest     Amount         `json:"max_mint_per_request"`              // 0 for none
+	MintApprovalThreshold Amount         `json:"mint_approval_threshold,omitempty"` // mints above it need MintApprovalsRequired admins
+	MintApprovalsRequired int            `json:"mint_approvals_required,omitempty"`
+	Burned                Amount         `json:"burned"`
+	Metadata              *TokenMetadata `json:"metadata,omitempty"`
+	PendingMetadata       *TokenMetadata `json:"pending_metadata,omitempty"` // new symbol awaiting admin review
+	MetadataRejection     string         `json:"metadata_rejection,omitempty"`
 }
 
 type TokenRequest struct {
-	RequestID   string `json:"request_id"`
-	NetworkAddr string `json:"network_addr"`
-	Status      string `json:"status"` // PENDING, APPROVED
-	TokenID     string `json:"token_id"`
-	Pincode     string `json:"pincode"` // Added pincode field
+	RequestID         string              `json:"request_id"`
+	NetworkAddr       string              `json:"network_addr"`
+	Status            string              `json:"status"` // PENDING, WAITLISTED, APPROVED, REJECTED, CANCELLED, REVOKED, TRANSFERRED
+	TokenID           string              `json:"token_id"`
+	WaitlistedAt      string              `json:"waitlisted_at,omitempty"` // approval time, while waiting for a token
+	Purpose           string              `json:"purpose,omitempty"`       // what the token is for, required for additional tokens
+	Reason            string              `json:"reason,omitempty"`        // why the request was rejected
+	Sequence          int                 `json:"sequence,omitempty"`      // position in the participant's request history, from 1
+	Pincode           string              `json:"pincode,omitempty"`       // legacy plaintext, hashed into PincodeCredential on the next write
+	PincodeCredential *PasswordCredential `json:"pincode_credential,omitempty"`
 }
 
 type MintRequest struct {
-	RequestID   string `json:"request_id"`
-	TokenID     string `json:"token_id"`
-	RequestedBy string `json:"requested_by"`
-	Amount      int    `json:"amount"`
-	Approved    bool   `json:"approved"`
+	RequestID         string         `json:"request_id"`
+	TokenID           string         `json:"token_id"`
+	RequestedBy       string         `json:"requested_by"`
+	Amount            Amount         `json:"amount"`
+	Status            string         `json:"status"`                       // PENDING, APPROVED, REJECTED, WITHDRAWN, EXPIRED
+	Approved          bool           `json:"approved"`                     // kept in step with Status for existing clients
+	Cancelled         bool           `json:"cancelled,omitempty"`          // legacy: the token was revoked or transferred before approval
+	Reason            string         `json:"reason,omitempty"`             // why the request was rejected
+	RequestedAt       string         `json:"requested_at,omitempty"`       // transaction time the request was made
+	ExpiresAt         string         `json:"expires_at,omitempty"`         // a request still pending at this time has expired
+	Approvals         []MintApproval `json:"approvals,omitempty"`          // admins that approved, see GetMintApprovals
+	ApprovalsRequired int            `json:"approvals_required,omitempty"` // set when filed, see mintApprovalsRequired
 }
 
-const maxTokens = 25
-
-// InitLedger initializes token pool
+// InitLedger bootstraps the admin registry and initializes token pool
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	for i := 1; i <= maxTokens; i++ {
-		tid := fmt.Sprintf("token_%d", i)
-		token := Token{TokenID: tid, Owner: "", Available: true, Minted: 0}
-		b, err := json.Marshal(token)
-		if err != nil {
-			return err
-		}
-		if err := ctx.GetStub().PutState(tid, b); err != nil {
-			return err
-		}
+	if err := s.checkPermission(ctx, permAdminister); err != nil {
+		return err
+	}
+	if err := s.initAdminRegistry(ctx); err != nil {
+		return err
 	}
 
-	return nil
+	// Re-invoking InitLedger never touches existing tokens
+	initialized, err := ctx.GetStub().GetState(tokenSeqKey)
+	if err != nil {
+		return err
+	}
+	if initialized != nil {
+		return nil
+	}
+	seq, err := s.ensureTokenPool(ctx)
+	if err != nil || seq > 0 {
+		return err
+	}
+	if _, err := s.createTokens(ctx, 0, initialTokens); err != nil {
+		return err
+	}
+	// A fresh ledger has no legacy amounts to migrate
+	return ctx.GetStub().PutState(amountsMigratedKey, []byte("true"))
 }
 
-// SubmitRegistration registers participant with generated network address
+// SubmitRegistration registers participant with a network address derived from the caller's certificate
 func (s *SmartContract) SubmitRegistration(ctx contractapi.TransactionContextInterface, name, passwordHash, country string) (string, error) {
-	hash := sha256.Sum256([]byte(name))
-	netAddr := hex.EncodeToString(hash[:])
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
+	}
+	if name, err = s.secretInput(ctx, transientNameKey, name); err != nil {
+		return "", err
+	}
+	if country, err = s.secretInput(ctx, transientCountryKey, country); err != nil {
+		return "", err
+	}
 
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", err
+	}
+	bound, err := ctx.GetStub().GetState(participantIDKey(clientID))
+	if err != nil {
+		return "", err
+	}
+	if bound != nil {
+		return "", fmt.Errorf("caller already registered as participant")
+	}
+
+	netAddr, err := deriveNetworkAddress(ctx)
+	if err != nil {
+		return "", err
+	}
 	exists, err := s.ParticipantExists(ctx, netAddr)
 	if err != nil {
 		return "", err
@@ -79,20 +146,25 @@ func (s *SmartContract) SubmitRegistration(ctx contractapi.TransactionContextInt
 		return "", fmt.Errorf("participant already exists")
 	}
 
-	clientID, err := ctx.GetClientIdentity().GetID()
+	cred, err := newPasswordCredential(ctx, passwordHash, netAddr)
 	if err != nil {
 		return "", err
 	}
 
-	p := Participant{Name: name, NetworkAddress: netAddr, ClientID: clientID, Approved: false, PasswordHash: passwordHash, Country: country, TokenID: ""}
-	b, _ := json.Marshal(p)
-	if err := ctx.GetStub().PutState(netAddr, b); err != nil {
+	p := Participant{Name: name, NetworkAddress: netAddr, ClientIDs: []string{clientID}, Approved: false, Status: statusRegistered, Credential: cred, Country: country}
+	if err := s.putParticipant(ctx, &p, true); err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(participantIDKey(clientID), []byte(netAddr)); err != nil {
 		return "", err
 	}
 	return netAddr, nil
 }
 
 func (s *SmartContract) ParticipantExists(ctx contractapi.TransactionContextInterface, networkAddress string) (bool, error) {
+	if err := s.requirePermission(ctx, permReadPublic); err != nil {
+		return false, err
+	}
 	b, err := ctx.GetStub().GetState(networkAddress)
 	if err != nil {
 		return false, err
@@ -100,48 +172,115 @@ func (s *SmartContract) ParticipantExists(ctx contractapi.TransactionContextInte
 	return b != nil, nil
 }
 
-// VerifyAdmin restricts to admin
-func (s *SmartContract) VerifyAdmin(ctx contractapi.TransactionContextInterface) error {
-	msp, err := ctx.GetClientIdentity().GetMSPID()
+// RequestTokenRequest allows participant to request token purchase; only if details match
+func (s *SmartContract) RequestTokenRequest(ctx contractapi.TransactionContextInterface, name, networkAddress, passwordHash, country, pincode, purpose string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
 	if err != nil {
 		return err
 	}
-	if msp != "Org1MSP" {
-		return fmt.Errorf("access denied: admin only")
+	if name, err = s.secretInput(ctx, transientNameKey, name); err != nil {
+		return err
+	}
+	if country, err = s.secretInput(ctx, transientCountryKey, country); err != nil {
+		return err
+	}
+	pincode, err = s.secretInput(ctx, transientPincodeKey, pincode)
+	if err != nil {
+		return err
 	}
-	return nil
-}
 
-// RequestTokenRequest allows participant to request token purchase; only if details match
-func (s *SmartContract) RequestTokenRequest(ctx contractapi.TransactionContextInterface, name, networkAddress, passwordHash, country, pincode string) error {
-	b, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || b == nil {
-		return fmt.Errorf("participant not found")
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
 	}
-	var p Participant
-	if err := json.Unmarshal(b, &p); err != nil {
+	if err := s.loadParticipantPII(ctx, p); err != nil {
 		return err
 	}
 
-	if p.Name != name || p.PasswordHash != passwordHash || p.Country != country {
+	if p.Name != name || p.Country != country || verifySecret(p.PasswordHash, p.Credential, passwordHash) != nil {
 		return fmt.Errorf("participant details do not match")
 	}
+	if err := checkVerified(p); err != nil {
+		return err
+	}
+	if err := checkNotFrozen(p); err != nil {
+		return err
+	}
 
 	// Validate pincode format (example: must be 6 digits)
 	if len(pincode) != 6 {
 		return fmt.Errorf("invalid pincode: must be 6 digits")
 	}
+	if purpose == "" && len(p.ownedTokenIDs()) > 0 {
+		return fmt.Errorf("purpose is required when requesting an additional token")
+	}
+	// An open request is never replaced; a closed one moves to the participant's history
+	seq, err := s.archiveTokenRequest(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
 
-	reqID := "tokenrequest_" + networkAddress
 	req := TokenRequest{
-		RequestID:   reqID,
+		RequestID:   "tokenrequest_" + networkAddress,
 		NetworkAddr: networkAddress,
 		Status:      "PENDING",
 		TokenID:     "",
 		Pincode:     pincode,
+		Purpose:     purpose,
+		Sequence:    seq,
+	}
+	return s.putTokenRequest(ctx, &req)
+}
+
+// sealPincode replaces a plaintext pincode on r with a salted credential, so the pincode never
+// reaches world state or key history
+func (r *TokenRequest) sealPincode(ctx contractapi.TransactionContextInterface) error {
+	if r.Pincode == "" {
+		return nil
+	}
+	cred, err := newPasswordCredential(ctx, r.Pincode, r.NetworkAddr)
+	if err != nil {
+		return err
+	}
+	r.PincodeCredential = cred
+	r.Pincode = ""
+	return nil
+}
+
+// MigratePincodes hashes the plaintext pincodes left on token requests filed before pincodes
+// were sealed and returns the number of requests rewritten (admin)
+func (s *SmartContract) MigratePincodes(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return 0, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	migrated := 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(kv.Key, "tokenrequest_") && !strings.HasPrefix(kv.Key, tokenRequestHistoryPrefix) {
+			continue
+		}
+		var r TokenRequest
+		if json.Unmarshal(kv.Value, &r) != nil || r.Pincode == "" {
+			continue
+		}
+		if err := s.putTokenRequest(ctx, &r); err != nil {
+			return 0, err
+		}
+		migrated++
 	}
-	rb, _ := json.Marshal(req)
-	return ctx.GetStub().PutState(reqID, rb)
+	return migrated, nil
 }
 
 // GetPendingTokenRequests returns admin pending token requests
@@ -170,7 +309,8 @@ func (s *SmartContract) GetPendingTokenRequests(ctx contractapi.TransactionConte
 	return list, nil
 }
 
-// ApproveTokenRequest admin approves token request and assigns token
+// ApproveTokenRequest admin approves token request and assigns a token, or waitlists the
+// request until one becomes available
 func (s *SmartContract) ApproveTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress string) error {
 	if err := s.VerifyAdmin(ctx); err != nil {
 		return err
@@ -185,251 +325,238 @@ func (s *SmartContract) ApproveTokenRequest(ctx contractapi.TransactionContextIn
 	if r.Status != "PENDING" {
 		return fmt.Errorf("request already processed")
 	}
-
-	tokenID, err := s.findAvailableToken(ctx)
+	p, err := s.getParticipant(ctx, networkAddress)
 	if err != nil {
 		return err
 	}
-	if tokenID == "" {
-		return fmt.Errorf("no tokens available")
-	}
-
-	r.Status = "APPROVED"
-	r.TokenID = tokenID
-	rb, _ = json.Marshal(r)
-
-	if err = ctx.GetStub().PutState(reqID, rb); err != nil {
+	if err := checkNotFrozen(p); err != nil {
 		return err
 	}
-
-	pb, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || pb == nil {
-		return fmt.Errorf("participant not found")
+	if err := checkVerified(p); err != nil {
+		return err
 	}
-	var p Participant
-	json.Unmarshal(pb, &p)
-	p.TokenID = tokenID
-	p.Approved = true
-	pb, _ = json.Marshal(p)
 
-	if err = ctx.GetStub().PutState(networkAddress, pb); err != nil {
+	tokenID, err := s.findAvailableToken(ctx)
+	if err != nil {
 		return err
 	}
-
-	tb, err := ctx.GetStub().GetState(tokenID)
-	if err != nil || tb == nil {
-		return fmt.Errorf("token not found")
+	if tokenID == "" {
+		return s.addToWaitlist(ctx, &r)
 	}
-	var t Token
-	json.Unmarshal(tb, &t)
-	t.Owner = networkAddress
-	t.Available = false
-	tb, _ = json.Marshal(t)
-
-	return ctx.GetStub().PutState(tokenID, tb)
+	return s.assignToken(ctx, &r, p, tokenID)
 }
 
-// findAvailableToken returns first available tokenID or empty string
+// findAvailableToken returns the first tokenID in the pool index or empty string
 func (s *SmartContract) findAvailableToken(ctx contractapi.TransactionContextInterface) (string, error) {
-	for i := 1; i <= maxTokens; i++ {
-		tid := fmt.Sprintf("token_%d", i)
-		b, err := ctx.GetStub().GetState(tid)
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(poolObjectType, nil)
+	if err != nil {
+		return "", err
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
 		if err != nil {
 			return "", err
 		}
-		if b == nil {
-			continue
-		}
-		var t Token
-		if err := json.Unmarshal(b, &t); err != nil {
+		tid := string(kv.Value)
+		b, err := ctx.GetStub().GetState(tid)
+		if err != nil {
 			return "", err
 		}
-		if t.Available {
+		var t Token
+		if b != nil && json.Unmarshal(b, &t) == nil && t.Available {
 			return tid, nil
 		}
 	}
 	return "", nil
 }
 
-// GetTokenAccess verifies password and returns token address
-func (s *SmartContract) GetTokenAccess(ctx contractapi.TransactionContextInterface, networkAddress, passwordHash string) (string, error) {
-	pb, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || pb == nil {
-		return "", fmt.Errorf("participant not found")
+// GetTokenAccess verifies password and ownership of tokenID and returns token address
+func (s *SmartContract) GetTokenAccess(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash string) (string, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
 	}
-	var p Participant
-	json.Unmarshal(pb, &p)
-	if p.PasswordHash != passwordHash {
-		return "", fmt.Errorf("password mismatch")
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
 	}
-	if p.TokenID == "" {
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return "", err
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return "", err
+	}
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return "", err
+	}
+	if len(p.ownedTokenIDs()) == 0 {
 		return "", fmt.Errorf("token not assigned")
 	}
-	return p.TokenID, nil
+	token, err := s.ownedToken(ctx, p, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return token.TokenID, nil
 }
 
 // RequestMintCoins allows token owner to request minting coins
-// RequestMintCoins verifies participant identity and password hash, then stores mint request
-func (s *SmartContract) RequestMintCoins(ctx contractapi.TransactionContextInterface, networkAddress string, passwordHash string, amount int) error {
-	// Fetch participant information by network address
-	partBytes, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || partBytes == nil {
-		return fmt.Errorf("participant not found")
-	}
-	var participant Participant
-	if err := json.Unmarshal(partBytes, &participant); err != nil {
-		return err
+// RequestMintCoins verifies participant identity and password hash, then stores a new mint request
+// for amount coins of tokenID and returns its ID; networkAddress may be empty to use the participant
+// bound to the caller
+func (s *SmartContract) RequestMintCoins(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash, amount string) (string, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
 	}
-
-	// Verify password hash matches stored hash
-	if participant.PasswordHash != passwordHash {
-		return fmt.Errorf("invalid password")
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
 	}
 
-	// Verify caller identity matches participant client ID
-	callerID, err := ctx.GetClientIdentity().GetID()
+	// Fetch participant bound to the caller's client identity
+	participant, err := s.callerParticipant(ctx, networkAddress)
 	if err != nil {
-		return err
+		return "", err
 	}
-	if participant.ClientID != callerID {
-		return fmt.Errorf("unauthorized caller")
+	if err := checkVerified(participant); err != nil {
+		return "", err
 	}
-
-	// Check token ownership
-	tokenBytes, err := ctx.GetStub().GetState(participant.TokenID)
-	if err != nil || tokenBytes == nil {
-		return fmt.Errorf("token not found")
+	if err := checkNotFrozen(participant); err != nil {
+		return "", err
 	}
-	var token Token
-	if err := json.Unmarshal(tokenBytes, &token); err != nil {
-		return err
+	if err := s.loadParticipantPII(ctx, participant); err != nil {
+		return "", err
 	}
-	if token.Owner != networkAddress {
-		return fmt.Errorf("caller is not token owner")
+
+	// Verify password hash matches stored hash
+	if err := verifySecret(participant.PasswordHash, participant.Credential, passwordHash); err != nil {
+		return "", err
 	}
 
-	// Create mint request key unique per token and participant
-	reqKey := fmt.Sprintf("mintrequest_%s_%s", participant.TokenID, networkAddress)
-	mintReq := MintRequest{
-		RequestID:   reqKey,
-		TokenID:     participant.TokenID,
-		RequestedBy: networkAddress,
-		Amount:      amount,
-		Approved:    false,
+	// Check token ownership
+	token, err := s.ownedToken(ctx, participant, tokenID)
+	if err != nil {
+		return "", err
 	}
-	reqBytes, err := json.Marshal(mintReq)
+
+	value, err := parsePositiveAmount(amount, token.decimals())
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := s.checkMintLimit(ctx, mintRequestKeyPrefix, token, participant.NetworkAddress, value, ""); err != nil {
+		return "", err
 	}
 
-	// Store the mint request on ledger
-	return ctx.GetStub().PutState(reqKey, reqBytes)
+	mr, err := s.newMintRequest(ctx, mintRequestKeyPrefix, token, participant.NetworkAddress, value)
+	if err != nil {
+		return "", err
+	}
+	return mr.RequestID, nil
 }
 
-// GetPendingMintRequests (admin)
+// GetPendingMintRequests lists the owner mint requests still open for approval (admin)
 func (s *SmartContract) GetPendingMintRequests(ctx contractapi.TransactionContextInterface) ([]MintRequest, error) {
 	if err := s.VerifyAdmin(ctx); err != nil {
 		return nil, err
 	}
-	it, err := ctx.GetStub().GetStateByRange("", "")
+	now, err := txTime(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer it.Close()
-	var reqs []MintRequest
-	for it.HasNext() {
-		kv, _ := it.Next()
-		if strings.HasPrefix(kv.Key, "mintrequest_") {
-			var r MintRequest
-			if json.Unmarshal(kv.Value, &r) == nil && !r.Approved {
-				reqs = append(reqs, r)
-			}
-		}
-	}
-	return reqs, nil
+	return s.listMintRequests(ctx, mintRequestKeyPrefix, func(r *MintRequest) bool { return r.open(now) })
 }
 
-// ApproveMintRequest approves mint request and mints coins
+// ApproveMintRequest records the calling admin's approval and mints the coins once the token's
+// approval policy is met, see SetMintApprovalPolicy
 func (s *SmartContract) ApproveMintRequest(ctx contractapi.TransactionContextInterface, requestID string) error {
 	if err := s.VerifyAdmin(ctx); err != nil {
 		return err
 	}
 
-	reqBytes, err := ctx.GetStub().GetState(requestID)
-	if err != nil || reqBytes == nil {
-		return fmt.Errorf("mint request not found")
-	}
-
-	var mr MintRequest
-	if err := json.Unmarshal(reqBytes, &mr); err != nil {
+	mr, err := s.pendingMintRequest(ctx, mintRequestKeyPrefix, requestID)
+	if err != nil {
 		return err
 	}
-
-	if mr.Approved {
-		return fmt.Errorf("mint request already approved")
+	if err := s.requireNotFrozen(ctx, mr.RequestedBy); err != nil {
+		return err
 	}
 
-	mr.Approved = true
-	updatedReqBytes, err := json.Marshal(mr)
+	token, err := s.getToken(ctx, mr.TokenID)
 	if err != nil {
 		return err
 	}
-
-	if err = ctx.GetStub().PutState(requestID, updatedReqBytes); err != nil {
-		return err
+	if token.Owner != mr.RequestedBy {
+		return fmt.Errorf("requester no longer owns token")
 	}
-
-	tokenBytes, err := ctx.GetStub().GetState(mr.TokenID)
-	if err != nil || tokenBytes == nil {
-		return fmt.Errorf("token not found")
+	if err := token.checkMintAllowed(mr.Amount); err != nil {
+		return err
 	}
-
-	var token Token
-	if err := json.Unmarshal(tokenBytes, &token); err != nil {
+	if err := s.checkMintLimit(ctx, mintRequestKeyPrefix, token, mr.RequestedBy, mr.Amount, mr.RequestID); err != nil {
 		return err
 	}
 
-	token.Minted += mr.Amount
-	updatedTokenBytes, err := json.Marshal(token)
+	quorum, err := s.recordMintApproval(ctx, mr, token.mintApprovalsRequired(mr))
 	if err != nil {
 		return err
 	}
+	if !quorum {
+		return s.putMintRequest(ctx, mr)
+	}
+	mr.close(mintRequestApproved, "")
+	if err := s.putMintRequest(ctx, mr); err != nil {
+		return err
+	}
 
-	return ctx.GetStub().PutState(mr.TokenID, updatedTokenBytes)
+	if token.TotalMinted, err = token.totalMinted().add(mr.Amount); err != nil {
+		return err
+	}
+	if token.Minted, err = token.Minted.add(mr.Amount); err != nil {
+		return err
+	}
+	return s.putToken(ctx, token)
 }
 
-func (s *SmartContract) GetWalletInfo(ctx contractapi.TransactionContextInterface, networkAddress, passwordHash string) (map[string]interface{}, error) {
-	pb, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || pb == nil {
-		return nil, fmt.Errorf("participant not found")
+// GetWalletInfo returns the wallet of tokenID for the participant bound to the caller;
+// networkAddress may be empty
+func (s *SmartContract) GetWalletInfo(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash string) (map[string]interface{}, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return nil, err
 	}
-	var p Participant
-	json.Unmarshal(pb, &p)
-
-	// Check password hash matches
-	if p.PasswordHash != passwordHash {
-		return nil, fmt.Errorf("incorrect password")
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return nil, err
 	}
 
-	// Verify caller client ID matches participant client ID
-	callerID, err := ctx.GetClientIdentity().GetID()
+	// Resolve participant bound to the caller's client identity
+	p, err := s.callerParticipant(ctx, networkAddress)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get caller identity: %v", err)
+		return nil, err
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return nil, err
 	}
-	if p.ClientID != callerID {
-		return nil, fmt.Errorf("unauthorized caller")
+
+	// Check password hash matches
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return nil, err
 	}
 
-	tb, err := ctx.GetStub().GetState(p.TokenID)
-	if err != nil || tb == nil {
-		return nil, fmt.Errorf("token not found")
+	t, err := s.ownedToken(ctx, p, tokenID)
+	if err != nil {
+		return nil, err
 	}
-	var t Token
-	json.Unmarshal(tb, &t)
 	return map[string]interface{}{
-		"networkAddress": p.NetworkAddress,
-		"tokenID":        t.TokenID,
-		"mintedCoins":    t.Minted,
+		"networkAddress":    p.NetworkAddress,
+		"tokenIDs":          p.ownedTokenIDs(),
+		"tokenID":           t.TokenID,
+		"decimals":          t.decimals(),
+		"mintedCoins":       t.Minted,
+		"totalMinted":       t.totalMinted(),
+		"maxSupply":         t.MaxSupply,
+		"maxMintPerRequest": t.MaxMintPerRequest,
+		"burned":            t.Burned,
 	}, nil
 }
 
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Reason codes accepted by FreezeParticipant
+const (
+	freezeCompromised   = "COMPROMISED"
+	freezeNonCompliant  = "NON_COMPLIANT"
+	freezeInvestigation = "INVESTIGATION"
+	freezeCourtOrder    = "COURT_ORDER"
+)
+
+var freezeReasons = map[string]bool{
+	freezeCompromised:   true,
+	freezeNonCompliant:  true,
+	freezeInvestigation: true,
+	freezeCourtOrder:    true,
+}
+
+// FreezeParticipant stops a participant, and the customers of its token, from any further
+// mutating action until an admin unfreezes it
+func (s *SmartContract) FreezeParticipant(ctx contractapi.TransactionContextInterface, networkAddress, reasonCode string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	if !freezeReasons[reasonCode] {
+		return fmt.Errorf("unknown freeze reason code: %s", reasonCode)
+	}
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	if p.Frozen {
+		return fmt.Errorf("participant already frozen")
+	}
+	adminID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+
+	p.Frozen = true
+	p.FreezeReason = reasonCode
+	p.FrozenBy = adminID
+	return s.putParticipant(ctx, p, false)
+}
+
+// UnfreezeParticipant lifts a freeze
+func (s *SmartContract) UnfreezeParticipant(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	if !p.Frozen {
+		return fmt.Errorf("participant not frozen")
+	}
+
+	p.Frozen = false
+	p.FreezeReason = ""
+	p.FrozenBy = ""
+	return s.putParticipant(ctx, p, false)
+}
+
+// checkNotFrozen refuses to act for a frozen participant
+func checkNotFrozen(p *Participant) error {
+	if p.Frozen {
+		return fmt.Errorf("participant is frozen: %s", p.FreezeReason)
+	}
+	return nil
+}
+
+// requireNotFrozen refuses to act for networkAddress if it is a frozen participant;
+// addresses that are not participants pass
+func (s *SmartContract) requireNotFrozen(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	pb, err := ctx.GetStub().GetState(networkAddress)
+	if err != nil {
+		return err
+	}
+	if pb == nil {
+		return nil
+	}
+	var p Participant
+	if json.Unmarshal(pb, &p) != nil || p.NetworkAddress != networkAddress {
+		return nil
+	}
+	return checkNotFrozen(&p)
+}
+
+// requireTokenOwnerNotFrozen refuses to act on a token whose owner is frozen
+func (s *SmartContract) requireTokenOwnerNotFrozen(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	tb, err := ctx.GetStub().GetState(tokenID)
+	if err != nil || tb == nil {
+		return fmt.Errorf("token not found")
+	}
+	var token Token
+	if err := json.Unmarshal(tb, &token); err != nil {
+		return err
+	}
+	if token.Owner == "" {
+		return nil
+	}
+	if err := s.requireNotFrozen(ctx, token.Owner); err != nil {
+		return fmt.Errorf("token owner is frozen")
+	}
+	return nil
+}
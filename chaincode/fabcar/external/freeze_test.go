@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreezeParticipant(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	tokenID := p.TokenIDs[0]
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "100")
+	assert.NoError(t, err)
+
+	err = h.Contract.FreezeParticipant(h.Ctx, netAddr, "BORED")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown freeze reason code")
+	assert.NoError(t, h.Contract.FreezeParticipant(h.Ctx, netAddr, freezeCompromised))
+
+	p, err = h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.True(t, p.Frozen)
+	assert.Equal(t, freezeCompromised, p.FreezeReason)
+
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "100")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "participant is frozen: COMPROMISED")
+
+	// Requests filed before the freeze cannot be approved either
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+
+	// Reads keep working
+	_, err = h.Contract.GetWalletInfo(h.Ctx, netAddr, tokenID, "pass123")
+	assert.NoError(t, err)
+
+	assert.NoError(t, h.Contract.UnfreezeParticipant(h.Ctx, netAddr))
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	err = h.Contract.UnfreezeParticipant(h.Ctx, netAddr)
+	assert.Error(t, err)
+}
+
+func TestFreezeRequiresAdmin(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+
+	err = h.Contract.FreezeParticipant(h.Ctx, netAddr, freezeInvestigation)
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.FreezeParticipant(h.Ctx, netAddr, freezeInvestigation))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "frozen")
+}
@@ -4,7 +4,9 @@ go 1.13
 
 require (
 	github.com/golang/mock v1.1.1
+	github.com/golang/protobuf v1.3.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200128192331-2d899240a7ed
 	github.com/hyperledger/fabric-contract-api-go v1.0.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200124220212-e9cfc186ba7b
 	github.com/stretchr/testify v1.4.0
 )
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	participantIDKeyPrefix  = "participantid_"
+	rebindRequestKeyPrefix  = "rebindrequest_"
+	deviceProposalKeyPrefix = "deviceproposal_"
+)
+
+// IdentityRebindRequest asks an admin to move a participant onto a new client identity,
+// e.g. after its certificate was re-enrolled
+type IdentityRebindRequest struct {
+	RequestID      string `json:"request_id"`
+	NetworkAddress string `json:"network_address"`
+	ClientID       string `json:"client_id"` // identity to bind, the one that filed the request
+	Status         string `json:"status"`    // PENDING, APPROVED
+	ApprovedBy     string `json:"approved_by"`
+}
+
+// DeviceProposal is a client identity a participant offered to bind, waiting for that identity
+// to accept with ConfirmDevice
+type DeviceProposal struct {
+	ProposalID     string `json:"proposal_id"`
+	NetworkAddress string `json:"network_address"`
+	ClientID       string `json:"client_id"`
+	ProposedAt     string `json:"proposed_at"`
+}
+
+// participantIDKey indexes a participant's network address by its bound client identity
+func participantIDKey(clientID string) string {
+	return participantIDKeyPrefix + clientID
+}
+
+func rebindRequestKey(networkAddress string) string {
+	return rebindRequestKeyPrefix + networkAddress
+}
+
+func deviceProposalKey(networkAddress, clientID string) string {
+	return deviceProposalKeyPrefix + networkAddress + "_" + clientID
+}
+
+// boundClientIDs returns the identities allowed to act for the participant, including
+// the single client_id of records written before multiple devices were supported
+func (p *Participant) boundClientIDs() []string {
+	if p.ClientID == "" {
+		return p.ClientIDs
+	}
+	for _, id := range p.ClientIDs {
+		if id == p.ClientID {
+			return p.ClientIDs
+		}
+	}
+	return append([]string{p.ClientID}, p.ClientIDs...)
+}
+
+func (p *Participant) boundTo(clientID string) bool {
+	for _, id := range p.boundClientIDs() {
+		if id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// callerParticipant resolves the participant bound to the calling client identity.
+// networkAddress is optional; when given it must belong to the caller.
+func (s *SmartContract) callerParticipant(ctx contractapi.TransactionContextInterface, networkAddress string) (*Participant, error) {
+	callerID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get caller identity: %v", err)
+	}
+
+	if networkAddress == "" {
+		ab, err := ctx.GetStub().GetState(participantIDKey(callerID))
+		if err != nil {
+			return nil, err
+		}
+		if ab == nil {
+			return nil, fmt.Errorf("no participant registered for caller")
+		}
+		networkAddress = string(ab)
+	}
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !p.boundTo(callerID) {
+		return nil, fmt.Errorf("unauthorized caller")
+	}
+	return p, nil
+}
+
+// requireUnboundIdentity fails if clientID already acts for a participant
+func requireUnboundIdentity(ctx contractapi.TransactionContextInterface, clientID string) error {
+	bound, err := ctx.GetStub().GetState(participantIDKey(clientID))
+	if err != nil {
+		return err
+	}
+	if bound != nil {
+		return fmt.Errorf("client identity already bound to a participant")
+	}
+	return nil
+}
+
+// authenticatedCaller resolves the caller's participant and checks its password
+func (s *SmartContract) authenticatedCaller(ctx contractapi.TransactionContextInterface, passwordHash string) (*Participant, error) {
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.callerParticipant(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// AddDevice proposes binding another enrolled client identity to the caller's participant;
+// the binding takes effect once that identity accepts it with ConfirmDevice
+func (s *SmartContract) AddDevice(ctx contractapi.TransactionContextInterface, passwordHash, clientID string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	if clientID == "" {
+		return fmt.Errorf("client identity must not be empty")
+	}
+	p, err := s.authenticatedCaller(ctx, passwordHash)
+	if err != nil {
+		return err
+	}
+	if err := requireUnboundIdentity(ctx, clientID); err != nil {
+		return err
+	}
+
+	proposedAt, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	proposal := DeviceProposal{ProposalID: deviceProposalKey(p.NetworkAddress, clientID), NetworkAddress: p.NetworkAddress, ClientID: clientID, ProposedAt: proposedAt}
+	pb, err := json.Marshal(proposal)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(proposal.ProposalID, pb)
+}
+
+// ConfirmDevice is called from a client identity proposed with AddDevice and binds it to the
+// participant at networkAddress, so no identity is bound without its own consent
+func (s *SmartContract) ConfirmDevice(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	callerID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get caller identity: %v", err)
+	}
+	key := deviceProposalKey(networkAddress, callerID)
+	pb, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return err
+	}
+	if pb == nil {
+		return fmt.Errorf("no device proposal for caller")
+	}
+	if err := requireUnboundIdentity(ctx, callerID); err != nil {
+		return err
+	}
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	p.ClientIDs = append(p.boundClientIDs(), callerID)
+	p.ClientID = ""
+	if err := s.putParticipant(ctx, p, false); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(participantIDKey(callerID), []byte(networkAddress)); err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// RemoveDevice unbinds a client identity from the caller's participant, or withdraws its pending
+// device proposal; the last bound identity cannot be removed
+func (s *SmartContract) RemoveDevice(ctx contractapi.TransactionContextInterface, passwordHash, clientID string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	p, err := s.authenticatedCaller(ctx, passwordHash)
+	if err != nil {
+		return err
+	}
+	if !p.boundTo(clientID) {
+		key := deviceProposalKey(p.NetworkAddress, clientID)
+		pending, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return err
+		}
+		if pending == nil {
+			return fmt.Errorf("client identity not bound to participant")
+		}
+		return ctx.GetStub().DelState(key)
+	}
+
+	var remaining []string
+	for _, id := range p.boundClientIDs() {
+		if id != clientID {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == 0 {
+		return fmt.Errorf("cannot remove the last bound identity")
+	}
+	p.ClientIDs = remaining
+	p.ClientID = ""
+	if err := s.putParticipant(ctx, p, false); err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(participantIDKey(clientID))
+}
+
+// RequestIdentityRebind is called from a new client identity of a participant that lost access
+// to its bound ones. The password proves ownership; an admin must still approve the request.
+func (s *SmartContract) RequestIdentityRebind(ctx contractapi.TransactionContextInterface, networkAddress, passwordHash string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return err
+	}
+	callerID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get caller identity: %v", err)
+	}
+	if err := requireUnboundIdentity(ctx, callerID); err != nil {
+		return err
+	}
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return err
+	}
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return err
+	}
+
+	req := IdentityRebindRequest{RequestID: rebindRequestKey(networkAddress), NetworkAddress: networkAddress, ClientID: callerID, Status: "PENDING"}
+	rb, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(req.RequestID, rb)
+}
+
+// GetPendingRebindRequests lists identity rebind requests awaiting admin approval
+func (s *SmartContract) GetPendingRebindRequests(ctx contractapi.TransactionContextInterface) ([]IdentityRebindRequest, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var pending []IdentityRebindRequest
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, rebindRequestKeyPrefix) {
+			var r IdentityRebindRequest
+			if err := json.Unmarshal(kv.Value, &r); err == nil && r.Status == "PENDING" {
+				pending = append(pending, r)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// ApproveIdentityRebind replaces all identities bound to the participant with the requesting one
+func (s *SmartContract) ApproveIdentityRebind(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	rb, err := ctx.GetStub().GetState(rebindRequestKey(networkAddress))
+	if err != nil || rb == nil {
+		return fmt.Errorf("rebind request not found")
+	}
+	var req IdentityRebindRequest
+	if err := json.Unmarshal(rb, &req); err != nil {
+		return err
+	}
+	if req.Status != "PENDING" {
+		return fmt.Errorf("rebind request already processed")
+	}
+	if err := requireUnboundIdentity(ctx, req.ClientID); err != nil {
+		return err
+	}
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	for _, id := range p.boundClientIDs() {
+		if err := ctx.GetStub().DelState(participantIDKey(id)); err != nil {
+			return err
+		}
+	}
+	p.ClientIDs = []string{req.ClientID}
+	p.ClientID = ""
+	if err := s.putParticipant(ctx, p, false); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(participantIDKey(req.ClientID), []byte(networkAddress)); err != nil {
+		return err
+	}
+
+	adminID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	req.Status = "APPROVED"
+	req.ApprovedBy = adminID
+	rb, err = json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(req.RequestID, rb)
+}
+
+// MigrateIdentityIndex adds the participantid_ entries missing for identities bound to
+// participants written before the index existed, pageSize records from startKey at a time; run it
+// again from the returned bookmark until that is empty. Migrated counts the entries added.
+func (s *SmartContract) MigrateIdentityIndex(ctx contractapi.TransactionContextInterface, startKey string, pageSize int) (*MigrationPage, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	records, bookmark, err := migrationPage(ctx, startKey, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MigrationPage{Bookmark: bookmark}
+	for _, kv := range records {
+		var p Participant
+		if json.Unmarshal(kv.Value, &p) != nil || p.NetworkAddress != kv.Key {
+			continue
+		}
+		for _, id := range p.boundClientIDs() {
+			bound, err := ctx.GetStub().GetState(participantIDKey(id))
+			if err != nil {
+				return nil, err
+			}
+			if bound != nil {
+				continue
+			}
+			if err := ctx.GetStub().PutState(participantIDKey(id), []byte(kv.Key)); err != nil {
+				return nil, err
+			}
+			res.Migrated++
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitRegistrationBindsIdentity(t *testing.T) {
+	h := NewTestHelper()
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	p, err := h.Contract.callerParticipant(h.Ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, netAddr, p.NetworkAddress)
+
+	// One client identity cannot register a second participant
+	_, err = h.CreateParticipant("Alice Two", "pass123", "USA")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered")
+}
+
+func TestCallerParticipantRejectsForeignAddress(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	aliceAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(aliceAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", aliceAddr, "pass123", "USA", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, aliceAddr))
+	alice, err := h.GetParticipant(aliceAddr)
+	assert.NoError(t, err)
+	tokenID := alice.TokenIDs[0]
+
+	// Mallory knows Alice's address and password but not her identity
+	h.SetIdentity("mallory-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.callerParticipant(h.Ctx, aliceAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthorized caller")
+
+	_, err = h.Contract.RequestMintCoins(h.Ctx, aliceAddr, tokenID, "pass123", "100")
+	assert.Error(t, err)
+	_, err = h.Contract.GetWalletInfo(h.Ctx, aliceAddr, tokenID, "pass123")
+	assert.Error(t, err)
+
+	// Without an address Mallory has no participant to act as
+	_, err = h.Contract.GetWalletInfo(h.Ctx, "", tokenID, "pass123")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no participant registered")
+
+	// Alice can omit her address entirely
+	h.SetAsAdmin()
+	_, err = h.Contract.RequestMintCoins(h.Ctx, "", tokenID, "pass123", "100")
+	assert.NoError(t, err)
+	wallet, err := h.Contract.GetWalletInfo(h.Ctx, "", tokenID, "pass123")
+	assert.NoError(t, err)
+	assert.Equal(t, aliceAddr, wallet["networkAddress"])
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAndRemoveDevice(t *testing.T) {
+	h := NewTestHelper()
+
+	h.SetIdentity("alice-laptop", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	err = h.Contract.AddDevice(h.Ctx, "wrong", "alice-phone")
+	assert.Error(t, err)
+	assert.NoError(t, h.Contract.AddDevice(h.Ctx, "pass123", "alice-phone"))
+
+	// The proposed device is not bound until it confirms from its own identity
+	h.SetIdentity("alice-phone", "Org1MSP", "token_owner")
+	_, err = h.Contract.callerParticipant(h.Ctx, "")
+	assert.Error(t, err)
+	h.SetIdentity("mallory-id", "Org1MSP", "token_owner")
+	err = h.Contract.ConfirmDevice(h.Ctx, netAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no device proposal")
+
+	h.SetIdentity("alice-phone", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ConfirmDevice(h.Ctx, netAddr))
+	assert.Nil(t, h.Stub.State[deviceProposalKey(netAddr, "alice-phone")])
+	err = h.Contract.ConfirmDevice(h.Ctx, netAddr)
+	assert.Error(t, err)
+
+	// The new device resolves to the same participant
+	p, err := h.Contract.callerParticipant(h.Ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, netAddr, p.NetworkAddress)
+	assert.ElementsMatch(t, []string{"alice-laptop", "alice-phone"}, p.ClientIDs)
+
+	// An identity can only act for one participant
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Bob", "bobpass", "UK")
+	assert.NoError(t, err)
+	err = h.Contract.AddDevice(h.Ctx, "bobpass", "alice-phone")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already bound")
+
+	h.SetIdentity("alice-phone", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RemoveDevice(h.Ctx, "pass123", "alice-laptop"))
+	err = h.Contract.RemoveDevice(h.Ctx, "pass123", "alice-phone")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "last bound identity")
+
+	h.SetIdentity("alice-laptop", "Org1MSP", "token_owner")
+	_, err = h.Contract.callerParticipant(h.Ctx, netAddr)
+	assert.Error(t, err)
+}
+
+func TestWithdrawDeviceProposal(t *testing.T) {
+	h := NewTestHelper()
+
+	h.SetIdentity("alice-laptop", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.Contract.AddDevice(h.Ctx, "pass123", "alice-phone"))
+	assert.NoError(t, h.Contract.RemoveDevice(h.Ctx, "pass123", "alice-phone"))
+
+	h.SetIdentity("alice-phone", "Org1MSP", "token_owner")
+	err = h.Contract.ConfirmDevice(h.Ctx, netAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no device proposal")
+}
+
+func TestIdentityRebind(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	h.SetIdentity("alice-old", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	// Alice re-enrolled and lost her old certificate
+	h.SetIdentity("alice-new", "Org1MSP", "token_owner")
+	_, err = h.Contract.callerParticipant(h.Ctx, netAddr)
+	assert.Error(t, err)
+
+	err = h.Contract.RequestIdentityRebind(h.Ctx, netAddr, "wrong")
+	assert.Error(t, err)
+	assert.NoError(t, h.Contract.RequestIdentityRebind(h.Ctx, netAddr, "pass123"))
+
+	// Only admins approve
+	err = h.Contract.ApproveIdentityRebind(h.Ctx, netAddr)
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingRebindRequests(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "alice-new", pending[0].ClientID)
+	assert.NoError(t, h.Contract.ApproveIdentityRebind(h.Ctx, netAddr))
+	err = h.Contract.ApproveIdentityRebind(h.Ctx, netAddr)
+	assert.Error(t, err)
+
+	h.SetIdentity("alice-new", "Org1MSP", "token_owner")
+	p, err := h.Contract.callerParticipant(h.Ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, netAddr, p.NetworkAddress)
+
+	h.SetIdentity("alice-old", "Org1MSP", "token_owner")
+	_, err = h.Contract.callerParticipant(h.Ctx, "")
+	assert.Error(t, err)
+}
+
+func TestLegacyClientIDStillBound(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	legacy := Participant{Name: "Bob", NetworkAddress: "addrbob", ClientID: "bob-id", PasswordHash: "bobhash", Country: "UK"}
+	pb, _ := json.Marshal(legacy)
+	h.Stub.State["addrbob"] = pb
+
+	// Records from before the identity index resolve once it is backfilled
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err := h.Contract.callerParticipant(h.Ctx, "")
+	assert.Error(t, err)
+	_, err = h.Contract.MigrateIdentityIndex(h.Ctx, "", 10)
+	assert.Error(t, err)
+	h.SetAsAdmin()
+	page, err := h.Contract.MigrateIdentityIndex(h.Ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Migrated)
+	page, err = h.Contract.MigrateIdentityIndex(h.Ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Zero(t, page.Migrated)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.AddDevice(h.Ctx, "bobhash", "bob-phone"))
+	h.SetIdentity("bob-phone", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ConfirmDevice(h.Ctx, "addrbob"))
+
+	p, err := h.GetParticipant("addrbob")
+	assert.NoError(t, err)
+	assert.Empty(t, p.ClientID)
+	assert.Equal(t, []string{"bob-id", "bob-phone"}, p.ClientIDs)
+}
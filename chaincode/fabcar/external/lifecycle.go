@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Participant onboarding states
+const (
+	statusRegistered  = "REGISTERED"
+	statusUnderReview = "UNDER_REVIEW"
+	statusVerified    = "VERIFIED"
+	statusRejected    = "REJECTED"
+	statusSuspended   = "SUSPENDED"
+	statusClosed      = "CLOSED"
+)
+
+// participantTransitions lists the states each state may move to
+var participantTransitions = map[string][]string{
+	statusRegistered:  {statusUnderReview, statusVerified, statusRejected, statusClosed},
+	statusUnderReview: {statusVerified, statusRejected},
+	statusVerified:    {statusSuspended, statusClosed},
+	statusSuspended:   {statusVerified, statusClosed},
+	statusRejected:    {statusClosed},
+}
+
+// onboardingStatus returns the participant's state. Records written before the lifecycle
+// existed count as verified once a token request was approved for them.
+func (p *Participant) onboardingStatus() string {
+	if p.Status != "" {
+		return p.Status
+	}
+	if p.Approved {
+		return statusVerified
+	}
+	return statusRegistered
+}
+
+// checkVerified refuses to act for participants that have not passed review or were suspended or closed since
+func checkVerified(p *Participant) error {
+	if status := p.onboardingStatus(); status != statusVerified {
+		return fmt.Errorf("participant is not verified: status %s", status)
+	}
+	return nil
+}
+
+// transitionParticipant moves a participant to the given state, recording reason and admin
+func (s *SmartContract) transitionParticipant(ctx contractapi.TransactionContextInterface, networkAddress, to, reason string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+
+	from := p.onboardingStatus()
+	allowed := false
+	for _, next := range participantTransitions[from] {
+		if next == to {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("cannot move participant from %s to %s", from, to)
+	}
+
+	adminID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	p.Status = to
+	p.StatusReason = reason
+	p.StatusUpdatedBy = adminID
+	return s.putParticipant(ctx, p, false)
+}
+
+// StartParticipantReview marks a registered participant as being reviewed
+func (s *SmartContract) StartParticipantReview(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	return s.transitionParticipant(ctx, networkAddress, statusUnderReview, "")
+}
+
+// VerifyParticipant accepts a registered or reviewed participant, or reinstates a suspended one
+func (s *SmartContract) VerifyParticipant(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	return s.transitionParticipant(ctx, networkAddress, statusVerified, "")
+}
+
+// RejectParticipant refuses a registration
+func (s *SmartContract) RejectParticipant(ctx contractapi.TransactionContextInterface, networkAddress, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("rejection reason must not be empty")
+	}
+	return s.transitionParticipant(ctx, networkAddress, statusRejected, reason)
+}
+
+// SuspendParticipant takes a verified participant out of service until it is verified again
+func (s *SmartContract) SuspendParticipant(ctx contractapi.TransactionContextInterface, networkAddress, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("suspension reason must not be empty")
+	}
+	return s.transitionParticipant(ctx, networkAddress, statusSuspended, reason)
+}
+
+// CloseParticipant ends a participant's lifecycle; closed participants cannot be reopened
+func (s *SmartContract) CloseParticipant(ctx contractapi.TransactionContextInterface, networkAddress, reason string) error {
+	return s.transitionParticipant(ctx, networkAddress, statusClosed, reason)
+}
+
+// GetPendingRegistrations lists participants that are registered or under review
+func (s *SmartContract) GetPendingRegistrations(ctx contractapi.TransactionContextInterface) ([]Participant, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var pending []Participant
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var p Participant
+		if json.Unmarshal(kv.Value, &p) != nil || p.NetworkAddress != kv.Key {
+			continue
+		}
+		if status := p.onboardingStatus(); status != statusRegistered && status != statusUnderReview {
+			continue
+		}
+		if err := s.loadParticipantPII(ctx, &p); err != nil {
+			return nil, err
+		}
+		p.clearSecrets()
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// MigrationPage reports one batch of a paged migration
+type MigrationPage struct {
+	Migrated int    `json:"migrated"`
+	Bookmark string `json:"bookmark"` // startKey of the next batch, empty once the ledger is done
+}
+
+// migrationPage reads up to pageSize records from startKey on, so a migration can spread its
+// work over several transactions, and returns the key the next batch starts from. Fabric refuses
+// paginated range queries in update transactions, hence the page is cut here.
+func migrationPage(ctx contractapi.TransactionContextInterface, startKey string, pageSize int) ([]keyValue, string, error) {
+	if pageSize <= 0 {
+		return nil, "", fmt.Errorf("page size must be positive")
+	}
+	iter, err := ctx.GetStub().GetStateByRange(startKey, "")
+	if err != nil {
+		return nil, "", err
+	}
+	defer iter.Close()
+
+	var records []keyValue
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, "", err
+		}
+		if len(records) == pageSize {
+			return records, kv.Key, nil
+		}
+		records = append(records, keyValue{kv.Key, kv.Value})
+	}
+	return records, "", nil
+}
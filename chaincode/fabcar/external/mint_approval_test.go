@@ -0,0 +1,180 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMintApprovalQuorum(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	assert.Error(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "50", 2), "only admins set the policy")
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "org2-admin", "Org2MSP"))
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "org1-second", "Org1MSP"))
+	err := h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "50", 4)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "only 3 admins")
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "50", 2))
+
+	// Mints up to the threshold still need a single admin
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	small, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "50")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, small))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	large, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "100")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, large))
+	err = h.Contract.ApproveMintRequest(h.Ctx, large)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already approved this mint request")
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("50"), token.Minted, "nothing minted before the quorum")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	approvals, err := h.Contract.GetMintApprovals(h.Ctx, large)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestPending, approvals.Status)
+	assert.Equal(t, 2, approvals.Required)
+	if assert.Len(t, approvals.Approvals, 1) {
+		assert.Equal(t, "test-client-id", approvals.Approvals[0].ClientID)
+	}
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Bob", "pass456", "UK")
+	assert.NoError(t, err)
+	_, err = h.Contract.GetMintApprovals(h.Ctx, large)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not the requester")
+
+	// A second admin, from another org, completes the quorum
+	h.SetIdentity("org2-admin", "Org2MSP", "admin")
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, large))
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("150"), token.Minted)
+	approvals, err = h.Contract.GetMintApprovals(h.Ctx, large)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestApproved, approvals.Status)
+	assert.Len(t, approvals.Approvals, 2)
+	assert.Equal(t, "Org2MSP", approvals.Approvals[1].MSPID)
+	assert.Error(t, h.Contract.ApproveMintRequest(h.Ctx, large))
+}
+
+func TestMintApprovalsFromRemovedAdminsDoNotCount(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "org2-admin", "Org2MSP"))
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "org1-second", "Org1MSP"))
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "0", 2))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+
+	h.SetIdentity("org2-admin", "Org2MSP", "admin")
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.RemoveAdmin(h.Ctx, "org2-admin"))
+	h.SetIdentity("org1-second", "Org1MSP", "admin")
+	assert.NoError(t, h.Contract.RemoveAdmin(h.Ctx, "org2-admin"))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	mr, err := h.GetMintRequest(mintReqID)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestPending, mr.Status)
+
+	h.SetIdentity("org1-second", "Org1MSP", "admin")
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("10"), token.Minted)
+
+	// Dropping the policy takes both admins and restores single-admin approval
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "", 0))
+	h.SetIdentity("org1-second", "Org1MSP", "admin")
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "", 0))
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, token.MintApprovalsRequired)
+}
+
+func TestAdminQuorumGuardsPolicyAndRegistry(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "org2-admin", "Org2MSP"))
+	err := h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "50", 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "at least 2 approvals")
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "50", 2))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	large, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "100")
+	assert.NoError(t, err)
+
+	// One admin can neither drop the policy nor remove the other admin
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "", 0))
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, token.MintApprovalsRequired)
+	err = h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "", 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already approved this admin proposal")
+	err = h.Contract.RemoveAdmin(h.Ctx, "org2-admin")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "2 admins must remain")
+
+	// Nor register a new admin alone
+	assert.NoError(t, h.Contract.AddAdmin(h.Ctx, "rogue", "Org1MSP"))
+	h.SetIdentity("rogue", "Org1MSP", "admin")
+	assert.Error(t, h.Contract.ApproveMintRequest(h.Ctx, large))
+	h.SetAsAdmin()
+	proposals, err := h.Contract.GetAdminProposals(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 2)
+	h.SetIdentity("org2-admin", "Org2MSP", "admin")
+	err = h.Contract.AddAdmin(h.Ctx, "rogue", "Org2MSP")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "different ADD_ADMIN proposal")
+	assert.NoError(t, h.Contract.CancelAdminProposal(h.Ctx, adminProposalKey(adminActionAdd, "rogue")))
+
+	// A second admin completes the policy change, which pending requests do not follow
+	assert.NoError(t, h.Contract.SetMintApprovalPolicy(h.Ctx, tokenID, "", 0))
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, token.MintApprovalsRequired)
+	proposals, err = h.Contract.GetAdminProposals(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, proposals)
+
+	approvals, err := h.Contract.GetMintApprovals(h.Ctx, large)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, approvals.Required)
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, large))
+	mr, err := h.GetMintRequest(large)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestPending, mr.Status)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, large))
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("100"), token.Minted)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParticipantMintLimits(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	const start = 1700000000
+
+	assert.Error(t, h.Contract.SetParticipantMintLimits(h.Ctx, netAddr, tokenID, "100", "150"), "only admins set limits")
+	h.SetAsAdmin()
+	err := h.Contract.SetParticipantMintLimits(h.Ctx, netAddr, tokenID, "200", "150")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds monthly limit")
+	assert.NoError(t, h.Contract.SetParticipantMintLimits(h.Ctx, netAddr, tokenID, "100", "150"))
+
+	// Limits apply when requesting
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: start}
+	h.NewTx()
+	first, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "60")
+	assert.NoError(t, err)
+	h.NewTx()
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "50")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "daily mint limit exceeded: 60 of 100 used, requested 50")
+
+	allowance, err := h.Contract.GetRemainingMintAllowance(h.Ctx, "", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("40"), allowance.DailyRemaining)
+	assert.Equal(t, Amount("90"), allowance.MonthlyRemaining)
+
+	// The day rolls over but the month does not
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: start + 25*3600}
+	h.NewTx()
+	second, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "50")
+	assert.NoError(t, err)
+	h.NewTx()
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "50")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "monthly mint limit exceeded")
+
+	// And again when approving, against the limits in force then
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetParticipantMintLimits(h.Ctx, netAddr, tokenID, "100", "100"))
+	err = h.Contract.ApproveMintRequest(h.Ctx, second)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "monthly mint limit exceeded: 60 of 100 used, requested 50")
+	assert.NoError(t, h.Contract.RejectMintRequest(h.Ctx, first, "over limit"))
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, second))
+
+	allowance, err = h.Contract.GetRemainingMintAllowance(h.Ctx, netAddr, tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("50"), allowance.MonthlyRemaining)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Bob", "pass456", "UK")
+	assert.NoError(t, err)
+	_, err = h.Contract.GetRemainingMintAllowance(h.Ctx, netAddr, tokenID)
+	assert.Error(t, err)
+
+	// Approved requests leave the month window after 30 days
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: start + 32*24*3600}
+	allowance, err = h.Contract.GetRemainingMintAllowance(h.Ctx, "", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("100"), allowance.DailyRemaining)
+	assert.Equal(t, Amount("100"), allowance.MonthlyRemaining)
+
+	// Zero caps remove the limits
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetParticipantMintLimits(h.Ctx, netAddr, tokenID, "0", "0"))
+	allowance, err = h.Contract.GetRemainingMintAllowance(h.Ctx, netAddr, tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, zeroAmount, allowance.DailyLimit)
+	assert.Empty(t, allowance.DailyRemaining)
+}
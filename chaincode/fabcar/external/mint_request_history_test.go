@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMintRequestsAreKeptAsHistory(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	// Several requests can be open at once, each under its own ID
+	h.NewTx()
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000100}
+	first, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "99")
+	assert.Error(t, err, "one request per transaction")
+	h.NewTx()
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000200}
+	second, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "20")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, first))
+
+	// A later request leaves the approved one as it was
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000300}
+	third, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "30")
+	assert.NoError(t, err)
+	mr, err := h.GetMintRequest(first)
+	assert.NoError(t, err)
+	assert.True(t, mr.Approved)
+	assert.Equal(t, Amount("10"), mr.Amount)
+	h.SetAsAdmin()
+	err = h.Contract.ApproveMintRequest(h.Ctx, first)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already approved")
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, third))
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("40"), token.Minted)
+
+	// The owner and admins read the token's history, oldest first
+	history, err := h.Contract.GetMintRequestHistory(h.Ctx, tokenID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	history, err = h.Contract.GetMintRequestHistory(h.Ctx, tokenID)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, []string{first, second, third}, []string{history[0].RequestID, history[1].RequestID, history[2].RequestID})
+		assert.True(t, history[0].Approved)
+		assert.False(t, history[1].Approved)
+		assert.Equal(t, "2023-11-14T22:16:40.000000000Z", history[1].RequestedAt)
+	}
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Bob", "pass456", "UK")
+	assert.NoError(t, err)
+	_, err = h.Contract.GetMintRequestHistory(h.Ctx, tokenID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+}
+
+func TestApproveMintRequestChecksKind(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	h.NewTx()
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+
+	// Only owner mint requests are approved by admins
+	h.Stub.State["custmintreq_"+tokenID+"_tx9"] = h.Stub.State[mintReqID]
+	h.SetAsAdmin()
+	err = h.Contract.ApproveMintRequest(h.Ctx, "custmintreq_"+tokenID+"_tx9")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectMintRequest(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	h.NewTx()
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+
+	assert.Error(t, h.Contract.RejectMintRequest(h.Ctx, mintReqID, "not needed"), "only admins reject")
+	h.SetAsAdmin()
+	err = h.Contract.RejectMintRequest(h.Ctx, mintReqID, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "reason is required")
+	assert.NoError(t, h.Contract.RejectMintRequest(h.Ctx, mintReqID, "supply review"))
+
+	mr, err := h.GetMintRequest(mintReqID)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestRejected, mr.Status)
+	assert.Equal(t, "supply review", mr.Reason)
+	assert.False(t, mr.Approved)
+
+	pending, err := h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already rejected: supply review")
+}
+
+func TestWithdrawMintRequest(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	h.NewTx()
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Bob", "pass456", "UK")
+	assert.NoError(t, err)
+	err = h.Contract.WithdrawMintRequest(h.Ctx, mintReqID, "pass456")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not the requester")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.WithdrawMintRequest(h.Ctx, mintReqID, "wrong"))
+	assert.NoError(t, h.Contract.WithdrawMintRequest(h.Ctx, mintReqID, "pass123"))
+	mr, err := h.GetMintRequest(mintReqID)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestWithdrawn, mr.Status)
+
+	err = h.Contract.WithdrawMintRequest(h.Ctx, mintReqID, "pass123")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already withdrawn")
+	h.SetAsAdmin()
+	assert.Error(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+}
+
+func TestMintRequestExpiry(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	assert.Error(t, h.Contract.SetMintRequestTTL(h.Ctx, "1h"), "only admins set the ttl")
+	h.SetAsAdmin()
+	assert.Error(t, h.Contract.SetMintRequestTTL(h.Ctx, "soon"))
+	assert.Error(t, h.Contract.SetMintRequestTTL(h.Ctx, "-1h"))
+	assert.NoError(t, h.Contract.SetMintRequestTTL(h.Ctx, "1h"))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000000}
+	h.NewTx()
+	expiring, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "10")
+	assert.NoError(t, err)
+	mr, err := h.GetMintRequest(expiring)
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-11-14T23:13:20.000000000Z", mr.ExpiresAt)
+
+	// Requests made with no ttl set stay open
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetMintRequestTTL(h.Ctx, "0"))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	lasting, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "20")
+	assert.NoError(t, err)
+
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000000 + 2*3600}
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, lasting, pending[0].RequestID)
+	}
+	err = h.Contract.ApproveMintRequest(h.Ctx, expiring)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.ExpireMintRequests(h.Ctx)
+	assert.Error(t, err, "only admins sweep")
+	h.SetAsAdmin()
+	n, err := h.Contract.ExpireMintRequests(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	mr, err = h.GetMintRequest(expiring)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestExpired, mr.Status)
+	n, err = h.Contract.ExpireMintRequests(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, lasting))
+	mr, err = h.GetMintRequest(lasting)
+	assert.NoError(t, err)
+	assert.Equal(t, mintRequestApproved, mr.Status)
+	assert.True(t, mr.Approved)
+}
+
+func TestLegacyMintRequestStatus(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	h.Stub.State["mintrequest_token_1_addr1"] = []byte(`{"request_id":"mintrequest_token_1_addr1","token_id":"token_1","amount":"3","approved":true}`)
+	h.Stub.State["mintrequest_token_1_addr2"] = []byte(`{"request_id":"mintrequest_token_1_addr2","token_id":"token_1","amount":"3","approved":false,"cancelled":true}`)
+	h.Stub.State["mintrequest_token_1_addr3"] = []byte(`{"request_id":"mintrequest_token_1_addr3","token_id":"token_1","amount":"3","approved":false}`)
+
+	h.SetAsAdmin()
+	history, err := h.Contract.GetMintRequestHistory(h.Ctx, "token_1")
+	assert.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, []string{mintRequestApproved, mintRequestRejected, mintRequestPending},
+			[]string{history[0].Status, history[1].Status, history[2].Status})
+	}
+	err = h.Contract.ApproveMintRequest(h.Ctx, "mintrequest_token_1_addr1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already approved")
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const mintLimitKeyPrefix = "mintlimit_"
+
+// Rolling windows of the mint limits, ending at the transaction time
+const (
+	mintLimitDay   = 24 * time.Hour
+	mintLimitMonth = 30 * 24 * time.Hour
+)
+
+// MintLimit caps the coins one requester may ask to mint on a token over rolling windows;
+// zero caps are unlimited
+type MintLimit struct {
+	TokenID        string `json:"token_id"`
+	NetworkAddress string `json:"network_address"`
+	Daily          Amount `json:"daily"`   // over the last 24 hours
+	Monthly        Amount `json:"monthly"` // over the last 30 days
+	SetBy          string `json:"set_by"`
+}
+
+// MintAllowance is what a requester may still ask to mint on a token, see GetRemainingMintAllowance
+type MintAllowance struct {
+	TokenID          string `json:"token_id"`
+	NetworkAddress   string `json:"network_address"`
+	DailyLimit       Amount `json:"daily_limit"` // 0 for none
+	MonthlyLimit     Amount `json:"monthly_limit"`
+	DailyRemaining   Amount `json:"daily_remaining,omitempty"` // unset without a daily limit
+	MonthlyRemaining Amount `json:"monthly_remaining,omitempty"`
+}
+
+// mintLimitKey keys the limits that apply to the requests of the kind given by requestPrefix
+func mintLimitKey(requestPrefix, tokenID, networkAddress string) string {
+	return mintLimitKeyPrefix + requestPrefix + tokenID + "_" + networkAddress
+}
+
+// getMintLimit returns the limits of networkAddress on tokenID, unlimited when none were set
+func (s *SmartContract) getMintLimit(ctx contractapi.TransactionContextInterface, requestPrefix, tokenID, networkAddress string) (*MintLimit, error) {
+	l := &MintLimit{TokenID: tokenID, NetworkAddress: networkAddress, Daily: zeroAmount, Monthly: zeroAmount}
+	b, err := ctx.GetStub().GetState(mintLimitKey(requestPrefix, tokenID, networkAddress))
+	if err != nil || b == nil {
+		return l, err
+	}
+	return l, json.Unmarshal(b, l)
+}
+
+// putMintLimit stores daily and monthly caps given in coins of the token; both zero removes them
+func (s *SmartContract) putMintLimit(ctx contractapi.TransactionContextInterface, requestPrefix string, token *Token, networkAddress, daily, monthly, setBy string) error {
+	d := token.decimals()
+	day, err := parseAmount(daily, d)
+	if err != nil {
+		return err
+	}
+	month, err := parseAmount(monthly, d)
+	if err != nil {
+		return err
+	}
+	if !day.isZero() && !month.isZero() && day.cmp(month) > 0 {
+		return fmt.Errorf("daily limit %s exceeds monthly limit %s", day.format(d), month.format(d))
+	}
+
+	key := mintLimitKey(requestPrefix, token.TokenID, networkAddress)
+	if day.isZero() && month.isZero() {
+		return ctx.GetStub().DelState(key)
+	}
+	b, err := json.Marshal(MintLimit{TokenID: token.TokenID, NetworkAddress: networkAddress, Daily: day, Monthly: month, SetBy: setBy})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, b)
+}
+
+// mintUsage sums what requestedBy asked to mint on tokenID in the day and month windows ending
+// at now, over requests that are approved or still open; the request except is left out
+func (s *SmartContract) mintUsage(ctx contractapi.TransactionContextInterface, requestPrefix, tokenID, requestedBy, except string, now time.Time) (Amount, Amount, error) {
+	nowAt := now.Format(txTimeLayout)
+	dayStart := now.Add(-mintLimitDay).Format(txTimeLayout)
+	monthStart := now.Add(-mintLimitMonth).Format(txTimeLayout)
+	list, err := s.listMintRequests(ctx, requestPrefix, func(r *MintRequest) bool {
+		return r.TokenID == tokenID && r.RequestedBy == requestedBy && r.RequestID != except &&
+			r.RequestedAt > monthStart && (r.Status == mintRequestApproved || r.open(nowAt))
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	day, month := zeroAmount, zeroAmount
+	for _, r := range list {
+		if month, err = month.add(r.Amount); err != nil {
+			return "", "", err
+		}
+		if r.RequestedAt > dayStart {
+			if day, err = day.add(r.Amount); err != nil {
+				return "", "", err
+			}
+		}
+	}
+	return day, month, nil
+}
+
+// checkMintLimit refuses a request by requestedBy for amount coins of token that would take it
+// past its limits; except is the request being approved, if any
+func (s *SmartContract) checkMintLimit(ctx contractapi.TransactionContextInterface, requestPrefix string, token *Token, requestedBy string, amount Amount, except string) error {
+	l, err := s.getMintLimit(ctx, requestPrefix, token.TokenID, requestedBy)
+	if err != nil {
+		return err
+	}
+	if l.Daily.isZero() && l.Monthly.isZero() {
+		return nil
+	}
+	now, err := txNow(ctx)
+	if err != nil {
+		return err
+	}
+	day, month, err := s.mintUsage(ctx, requestPrefix, token.TokenID, requestedBy, except, now)
+	if err != nil {
+		return err
+	}
+
+	d := token.decimals()
+	for _, w := range []struct {
+		name        string
+		limit, used Amount
+	}{{"daily", l.Daily, day}, {"monthly", l.Monthly, month}} {
+		if w.limit.isZero() {
+			continue
+		}
+		total, err := w.used.add(amount)
+		if err != nil {
+			return err
+		}
+		if total.cmp(w.limit) > 0 {
+			return fmt.Errorf("%s mint limit exceeded: %s of %s used, requested %s", w.name, w.used.format(d), w.limit.format(d), amount.format(d))
+		}
+	}
+	return nil
+}
+
+// mintAllowance reports the limits of networkAddress on tokenID and what is left of them
+func (s *SmartContract) mintAllowance(ctx contractapi.TransactionContextInterface, requestPrefix, tokenID, networkAddress string) (*MintAllowance, error) {
+	l, err := s.getMintLimit(ctx, requestPrefix, tokenID, networkAddress)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txNow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	day, month, err := s.mintUsage(ctx, requestPrefix, tokenID, networkAddress, "", now)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := func(limit, used Amount) Amount {
+		if limit.isZero() {
+			return ""
+		}
+		if used.cmp(limit) >= 0 {
+			return zeroAmount
+		}
+		left, _ := limit.sub(used)
+		return left
+	}
+	return &MintAllowance{
+		TokenID:          tokenID,
+		NetworkAddress:   networkAddress,
+		DailyLimit:       l.Daily,
+		MonthlyLimit:     l.Monthly,
+		DailyRemaining:   remaining(l.Daily, day),
+		MonthlyRemaining: remaining(l.Monthly, month),
+	}, nil
+}
+
+// SetParticipantMintLimits caps the coins a participant may request to mint on tokenID per
+// rolling day and 30-day month; zero removes a cap (admin)
+func (s *SmartContract) SetParticipantMintLimits(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, daily, monthly string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	if _, err := s.getParticipant(ctx, networkAddress); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	callerID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	return s.putMintLimit(ctx, mintRequestKeyPrefix, token, networkAddress, daily, monthly, callerID)
+}
+
+// GetRemainingMintAllowance reports how much more networkAddress may request to mint on tokenID
+// in the current rolling day and month; open to the participant and admins. networkAddress may
+// be empty to use the participant bound to the caller.
+func (s *SmartContract) GetRemainingMintAllowance(ctx contractapi.TransactionContextInterface, networkAddress, tokenID string) (*MintAllowance, error) {
+	if s.VerifyAdmin(ctx) != nil {
+		if err := s.requirePermission(ctx, permParticipate); err != nil {
+			return nil, err
+		}
+		p, err := s.callerParticipant(ctx, networkAddress)
+		if err != nil {
+			return nil, err
+		}
+		networkAddress = p.NetworkAddress
+	}
+	if _, err := s.getToken(ctx, tokenID); err != nil {
+		return nil, err
+	}
+	return s.mintAllowance(ctx, mintRequestKeyPrefix, tokenID, networkAddress)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Mint request statuses
+const (
+	mintRequestPending   = "PENDING"
+	mintRequestApproved  = "APPROVED"
+	mintRequestRejected  = "REJECTED"
+	mintRequestWithdrawn = "WITHDRAWN"
+	mintRequestExpired   = "EXPIRED"
+)
+
+const (
+	mintRequestKeyPrefix         = "mintrequest_" // owner requests to mint on the token, approved by an admin
+	customerMintRequestKeyPrefix = "custmintreq_" // customer requests for minted coins, approved by the owner
+
+	mintRequestTTLKey = "config_mint_request_ttl"
+)
+
+// status reads requests stored before Status was recorded; a legacy cancelled request was
+// closed by a token revocation or transfer, which now rejects it
+func (r *MintRequest) status() string {
+	switch {
+	case r.Status != "":
+		return r.Status
+	case r.Approved:
+		return mintRequestApproved
+	case r.Cancelled:
+		return mintRequestRejected
+	}
+	return mintRequestPending
+}
+
+// open reports whether the request is pending and not past its expiry at now
+func (r *MintRequest) open(now string) bool {
+	return r.status() == mintRequestPending && (r.ExpiresAt == "" || now < r.ExpiresAt)
+}
+
+// close settles a pending request with status; the request is never changed afterwards
+func (r *MintRequest) close(status, reason string) {
+	r.Status = status
+	r.Approved = status == mintRequestApproved
+	r.Reason = reason
+}
+
+// mintRequestTTL is how long new mint requests stay open; zero, the default, keeps them open
+// until handled
+func (s *SmartContract) mintRequestTTL(ctx contractapi.TransactionContextInterface) (time.Duration, error) {
+	b, err := ctx.GetStub().GetState(mintRequestTTLKey)
+	if err != nil || b == nil {
+		return 0, err
+	}
+	return time.ParseDuration(string(b))
+}
+
+// SetMintRequestTTL sets how long new mint requests stay open before they expire, as a duration
+// such as "72h"; "0" lets them stay open until handled (admin)
+func (s *SmartContract) SetMintRequestTTL(ctx contractapi.TransactionContextInterface, ttl string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		return fmt.Errorf("invalid ttl %q: %v", ttl, err)
+	}
+	if d < 0 {
+		return fmt.Errorf("ttl must not be negative")
+	}
+	return ctx.GetStub().PutState(mintRequestTTLKey, []byte(d.String()))
+}
+
+// newMintRequest stores a pending request by requestedBy for amount coins of token. The ID is
+// unique to the transaction, so a request never replaces an earlier one and each stays on the
+// ledger as history once settled. Owner mint requests keep the admin approvals the token's
+// policy requires at this point.
+func (s *SmartContract) newMintRequest(ctx contractapi.TransactionContextInterface, prefix string, token *Token, requestedBy string, amount Amount) (*MintRequest, error) {
+	now, err := txNow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := s.mintRequestTTL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &MintRequest{
+		RequestID:   prefix + token.TokenID + "_" + ctx.GetStub().GetTxID(),
+		TokenID:     token.TokenID,
+		RequestedBy: requestedBy,
+		Amount:      amount,
+		Status:      mintRequestPending,
+		RequestedAt: now.Format(txTimeLayout),
+	}
+	if ttl > 0 {
+		r.ExpiresAt = now.Add(ttl).Format(txTimeLayout)
+	}
+	if prefix == mintRequestKeyPrefix {
+		r.ApprovalsRequired = token.approvalsRequired(amount)
+	}
+	existing, err := ctx.GetStub().GetState(r.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("mint request %s already exists", r.RequestID)
+	}
+	return r, s.putMintRequest(ctx, r)
+}
+
+// getMintRequest loads a mint request of the kind given by its key prefix
+func (s *SmartContract) getMintRequest(ctx contractapi.TransactionContextInterface, prefix, requestID string) (*MintRequest, error) {
+	b, err := ctx.GetStub().GetState(requestID)
+	if err != nil || b == nil || !strings.HasPrefix(requestID, prefix) {
+		return nil, fmt.Errorf("mint request not found")
+	}
+	var r MintRequest
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	r.Status = r.status()
+	return &r, nil
+}
+
+// pendingMintRequest loads a mint request that can still be approved, rejected or withdrawn
+func (s *SmartContract) pendingMintRequest(ctx contractapi.TransactionContextInterface, prefix, requestID string) (*MintRequest, error) {
+	r, err := s.getMintRequest(ctx, prefix, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != mintRequestPending {
+		if r.Reason != "" {
+			return nil, fmt.Errorf("mint request already %s: %s", strings.ToLower(r.Status), r.Reason)
+		}
+		return nil, fmt.Errorf("mint request already %s", strings.ToLower(r.Status))
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !r.open(now) {
+		return nil, fmt.Errorf("mint request expired at %s", r.ExpiresAt)
+	}
+	return r, nil
+}
+
+func (s *SmartContract) putMintRequest(ctx contractapi.TransactionContextInterface, r *MintRequest) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(r.RequestID, b)
+}
+
+// listMintRequests returns the mint requests under prefix that match keep
+func (s *SmartContract) listMintRequests(ctx contractapi.TransactionContextInterface, prefix string, keep func(*MintRequest) bool) ([]MintRequest, error) {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	list := []MintRequest{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(kv.Key, prefix) {
+			continue
+		}
+		var r MintRequest
+		if json.Unmarshal(kv.Value, &r) != nil {
+			continue
+		}
+		r.Status = r.status()
+		if keep(&r) {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+// rejectPendingMintRequests rejects every pending request requestedBy made to mint on tokenID
+func (s *SmartContract) rejectPendingMintRequests(ctx contractapi.TransactionContextInterface, tokenID, requestedBy, reason string) error {
+	pending, err := s.listMintRequests(ctx, mintRequestKeyPrefix, func(r *MintRequest) bool {
+		return r.TokenID == tokenID && r.RequestedBy == requestedBy && r.Status == mintRequestPending
+	})
+	if err != nil {
+		return err
+	}
+	for i := range pending {
+		pending[i].close(mintRequestRejected, reason)
+		if err := s.putMintRequest(ctx, &pending[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RejectMintRequest closes a pending mint request with reason (admin)
+func (s *SmartContract) RejectMintRequest(ctx contractapi.TransactionContextInterface, requestID, reason string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	r, err := s.pendingMintRequest(ctx, mintRequestKeyPrefix, requestID)
+	if err != nil {
+		return err
+	}
+	r.close(mintRequestRejected, reason)
+	return s.putMintRequest(ctx, r)
+}
+
+// WithdrawMintRequest lets the participant that made a pending mint request take it back
+func (s *SmartContract) WithdrawMintRequest(ctx contractapi.TransactionContextInterface, requestID, passwordHash string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return err
+	}
+	r, err := s.pendingMintRequest(ctx, mintRequestKeyPrefix, requestID)
+	if err != nil {
+		return err
+	}
+	p, err := s.callerParticipant(ctx, "")
+	if err != nil {
+		return err
+	}
+	if p.NetworkAddress != r.RequestedBy {
+		return fmt.Errorf("caller is not the requester")
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return err
+	}
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return err
+	}
+
+	r.close(mintRequestWithdrawn, "")
+	return s.putMintRequest(ctx, r)
+}
+
+// ExpireMintRequests marks every pending mint request past its expiry as EXPIRED; returns how
+// many were expired (admin)
+func (s *SmartContract) ExpireMintRequests(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return 0, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, prefix := range []string{mintRequestKeyPrefix, customerMintRequestKeyPrefix} {
+		list, err := s.listMintRequests(ctx, prefix, func(r *MintRequest) bool {
+			return r.Status == mintRequestPending && !r.open(now)
+		})
+		if err != nil {
+			return 0, err
+		}
+		for i := range list {
+			list[i].close(mintRequestExpired, "")
+			if err := s.putMintRequest(ctx, &list[i]); err != nil {
+				return 0, err
+			}
+		}
+		expired += len(list)
+	}
+	return expired, nil
+}
+
+// GetMintRequestHistory lists every mint request made on tokenID, the owners' and the
+// customers', oldest first; open to the token owner and admins
+func (s *SmartContract) GetMintRequestHistory(ctx contractapi.TransactionContextInterface, tokenID string) ([]MintRequest, error) {
+	if s.VerifyAdmin(ctx) != nil {
+		if err := s.requirePermission(ctx, permManageToken); err != nil {
+			return nil, err
+		}
+		owner, err := s.callerParticipant(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		token, err := s.getToken(ctx, tokenID)
+		if err != nil {
+			return nil, err
+		}
+		if token.Owner != owner.NetworkAddress {
+			return nil, fmt.Errorf("caller is not token owner")
+		}
+	}
+
+	forToken := func(r *MintRequest) bool { return r.TokenID == tokenID }
+	list, err := s.listMintRequests(ctx, mintRequestKeyPrefix, forToken)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.listMintRequests(ctx, customerMintRequestKeyPrefix, forToken)
+	if err != nil {
+		return nil, err
+	}
+	list = append(list, customer...)
+	// Requests made before RequestedAt was recorded sort first
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].RequestedAt != list[j].RequestedAt {
+			return list[i].RequestedAt < list[j].RequestedAt
+		}
+		return list[i].RequestID < list[j].RequestID
+	})
+	return list, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// mockStub holds an in-memory map for ledger state
+type mockStub struct {
+	shim.ChaincodeStubInterface
+	State        map[string][]byte
+	PrivateState map[string]map[string][]byte // collection -> key -> value
+	TxID         string
+	TxTimestamp  *timestamp.Timestamp
+	Transient    map[string][]byte
+}
+
+func (m *mockStub) GetTxID() string {
+	return m.TxID
+}
+
+// GetTxTimestamp returns TxTimestamp, the zero time when unset
+func (m *mockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if m.TxTimestamp == nil {
+		return &timestamp.Timestamp{}, nil
+	}
+	return m.TxTimestamp, nil
+}
+
+func (m *mockStub) GetTransient() (map[string][]byte, error) {
+	return m.Transient, nil
+}
+
+func (m *mockStub) GetState(key string) ([]byte, error) {
+	return m.State[key], nil
+}
+
+func (m *mockStub) PutState(key string, value []byte) error {
+	m.State[key] = value
+	return nil
+}
+
+func (m *mockStub) DelState(key string) error {
+	delete(m.State, key)
+	return nil
+}
+
+func (m *mockStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return m.PrivateState[collection][key], nil
+}
+
+func (m *mockStub) PutPrivateData(collection, key string, value []byte) error {
+	if m.PrivateState == nil {
+		m.PrivateState = make(map[string]map[string][]byte)
+	}
+	if m.PrivateState[collection] == nil {
+		m.PrivateState[collection] = make(map[string][]byte)
+	}
+	m.PrivateState[collection][key] = value
+	return nil
+}
+
+func (m *mockStub) DelPrivateData(collection, key string) error {
+	delete(m.PrivateState[collection], key)
+	return nil
+}
+
+// GetStateByRange iterates simple keys in sorted order; empty bounds are open.
+// Like the peer it skips composite keys.
+func (m *mockStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return m.iterate(func(k string) bool {
+		return !strings.HasPrefix(k, compositeKeyNamespace) &&
+			(startKey == "" || k >= startKey) && (endKey == "" || k < endKey)
+	}), nil
+}
+
+const compositeKeyNamespace = "\x00"
+
+// CreateCompositeKey uses the peer's encoding so composite keys sort the same way
+func (m *mockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	key := compositeKeyNamespace + objectType + "\x00"
+	for _, attr := range attributes {
+		key += attr + "\x00"
+	}
+	return key, nil
+}
+
+func (m *mockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	parts := strings.Split(strings.TrimPrefix(compositeKey, compositeKeyNamespace), "\x00")
+	if len(parts) < 2 {
+		return "", nil, fmt.Errorf("invalid composite key")
+	}
+	return parts[0], parts[1 : len(parts)-1], nil
+}
+
+func (m *mockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, _ := m.CreateCompositeKey(objectType, keys)
+	return m.iterate(func(k string) bool { return strings.HasPrefix(k, prefix) }), nil
+}
+
+// iterate snapshots the matching keys in sorted order
+func (m *mockStub) iterate(match func(string) bool) *mockIterator {
+	var keys []string
+	for k := range m.State {
+		if match(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	it := &mockIterator{}
+	for _, k := range keys {
+		it.kvs = append(it.kvs, &queryresult.KV{Key: k, Value: m.State[k]})
+	}
+	return it
+}
+
+// mockIterator walks a fixed snapshot of key/value pairs
+type mockIterator struct {
+	shim.StateQueryIteratorInterface
+	kvs []*queryresult.KV
+	pos int
+}
+
+func (m *mockIterator) HasNext() bool {
+	return m.pos < len(m.kvs)
+}
+
+func (m *mockIterator) Next() (*queryresult.KV, error) {
+	kv := m.kvs[m.pos]
+	m.pos++
+	return kv, nil
+}
+
+func (m *mockIterator) Close() error {
+	return nil
+}
+
+// mockClientIdentity mocks the client identity
+type mockClientIdentity struct{}
+
+func (m *mockClientIdentity) GetID() (string, error) {
+	return "test-client-id", nil
+}
+
+func (m *mockClientIdentity) GetMSPID() (string, error) {
+	return "Org1MSP", nil // Admin MSP
+}
+
+func (m *mockClientIdentity) GetAttributeValue(attr string) (string, bool, error) {
+	if attr == "role" {
+		return "admin,token_owner", true, nil // Acts as both admin and participant
+	}
+	return "", false, nil
+}
+
+func (m *mockClientIdentity) AssertAttributeValue(attr, val string) error {
+	return assertMockAttribute(m, attr, val)
+}
+
+// GetX509Certificate returns a certificate whose raw bytes are the identity's ID
+func (m *mockClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	id, _ := m.GetID()
+	return &x509.Certificate{Raw: []byte(id)}, nil
+}
+
+// mockNonAdminIdentity mocks a non-admin client identity
+type mockNonAdminIdentity struct{ mockClientIdentity }
+
+func (m *mockNonAdminIdentity) GetMSPID() (string, error) {
+	return "Org2MSP", nil // Non-admin MSP
+}
+
+// mockCustomIdentity mocks a client identity with a configurable ID, MSP and attribute set
+type mockCustomIdentity struct {
+	mockClientIdentity
+	id    string
+	mspID string
+	attrs map[string]string
+}
+
+func (m *mockCustomIdentity) GetID() (string, error) {
+	return m.id, nil
+}
+
+func (m *mockCustomIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Raw: []byte(m.id)}, nil
+}
+
+func (m *mockCustomIdentity) GetMSPID() (string, error) {
+	return m.mspID, nil
+}
+
+func (m *mockCustomIdentity) GetAttributeValue(attr string) (string, bool, error) {
+	val, ok := m.attrs[attr]
+	return val, ok, nil
+}
+
+func (m *mockCustomIdentity) AssertAttributeValue(attr, val string) error {
+	return assertMockAttribute(m, attr, val)
+}
+
+// assertMockAttribute mirrors cid.AssertAttributeValue on top of GetAttributeValue
+func assertMockAttribute(id cid.ClientIdentity, attr, val string) error {
+	got, ok, err := id.GetAttributeValue(attr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("attribute '%s' was not found", attr)
+	}
+	if got != val {
+		return fmt.Errorf("attribute '%s' equals '%s', not '%s'", attr, got, val)
+	}
+	return nil
+}
+
+// mockContext implements TransactionContextInterface
+type mockContext struct {
+	contractapi.TransactionContext
+	stub           shim.ChaincodeStubInterface
+	clientIdentity cid.ClientIdentity
+}
+
+func (m *mockContext) GetStub() shim.ChaincodeStubInterface {
+	return m.stub
+}
+
+func (m *mockContext) GetClientIdentity() cid.ClientIdentity {
+	return m.clientIdentity
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParticipantOwnsMultipleTokens(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, first := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	// Additional tokens need a stated purpose
+	err := h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "purpose is required")
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "loyalty points"))
+	r, err := h.GetTokenRequest(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "PENDING", r.Status)
+	assert.Equal(t, "loyalty points", r.Purpose)
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Len(t, p.TokenIDs, 2)
+	assert.Equal(t, first, p.TokenIDs[0])
+	second := p.TokenIDs[1]
+
+	// Token-scoped calls name the token and are checked against the owned list
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, first, "pass123", "10")
+	assert.NoError(t, err)
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, second, "pass123", "20")
+	assert.NoError(t, err)
+	mr, err := h.GetMintRequest(mintReqID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("20"), mr.Amount)
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, "token_25", "pass123", "10")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, "", "pass123", "10")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token id is required")
+
+	access, err := h.Contract.GetTokenAccess(h.Ctx, netAddr, second, "pass123")
+	assert.NoError(t, err)
+	assert.Equal(t, second, access)
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "token_25", "pass123")
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mr.RequestID))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	wallet, err := h.Contract.GetWalletInfo(h.Ctx, netAddr, second, "pass123")
+	assert.NoError(t, err)
+	assert.Equal(t, second, wallet["tokenID"])
+	assert.Equal(t, Amount("20"), wallet["mintedCoins"])
+	assert.Equal(t, []string{first, second}, wallet["tokenIDs"])
+
+	// Losing one token leaves the others in place
+	h.SetAsAdmin()
+	_, err = h.Contract.RevokeToken(h.Ctx, first, revokeSettle, "fraud")
+	assert.NoError(t, err)
+	p, err = h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second}, p.TokenIDs)
+	assert.True(t, p.Approved)
+}
+
+func TestTokenOwnerTakesOverAnotherToken(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	bobAddr, bobToken := setupTokenOwner(t, h, "bob-id", "Bob")
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	transferID, err := h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, tokenID, bobAddr)
+	assert.NoError(t, err)
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.AcceptTokenOwnershipTransfer(h.Ctx, transferID))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenOwnershipTransfer(h.Ctx, transferID))
+
+	bob, err := h.GetParticipant(bobAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bobToken, tokenID}, bob.TokenIDs)
+}
+
+func TestLegacySingleTokenParticipant(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	// Records written before multiple tokens keep their token in token_id
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(h.Stub.State[netAddr], &raw))
+	delete(raw, "token_ids")
+	raw["token_id"] = tokenID
+	h.Stub.State[netAddr], _ = json.Marshal(raw)
+
+	access, err := h.Contract.GetTokenAccess(h.Ctx, netAddr, tokenID, "pass123")
+	assert.NoError(t, err)
+	assert.Equal(t, tokenID, access)
+
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "second shop"))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Empty(t, p.TokenID)
+	assert.Len(t, p.TokenIDs, 2)
+	assert.Equal(t, tokenID, p.TokenIDs[0])
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// migrateNetworkAddresses runs MigrateNetworkAddresses batch by batch over the whole ledger
+func migrateNetworkAddresses(h *TestHelper) (map[string]string, error) {
+	moved := make(map[string]string)
+	for startKey := ""; ; {
+		res, err := h.Contract.MigrateNetworkAddresses(h.Ctx, startKey, 3)
+		if err != nil {
+			return nil, err
+		}
+		for oldAddr, newAddr := range res.Moved {
+			moved[oldAddr] = newAddr
+		}
+		if res.Bookmark == "" {
+			return moved, nil
+		}
+		startKey = res.Bookmark
+	}
+}
+
+func TestSameNameGetsDistinctAddresses(t *testing.T) {
+	h := NewTestHelper()
+
+	h.SetIdentity("alice-1", "Org1MSP", "token_owner")
+	addr1, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	h.SetIdentity("alice-2", "Org1MSP", "token_owner")
+	addr2, err := h.CreateParticipant("Alice", "pass456", "USA")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, addr1, addr2)
+	assert.NotEqual(t, legacyNetworkAddress("Alice"), addr1)
+}
+
+func TestMigrateNetworkAddresses(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	oldAddr := legacyNetworkAddress("Bob")
+	legacy := Participant{Name: "Bob", NetworkAddress: oldAddr, ClientID: "bob-id", PasswordHash: "bobhash", Country: "UK"}
+	pb, _ := json.Marshal(legacy)
+	h.Stub.State[oldAddr] = pb
+	h.Stub.State[participantIDKey("bob-id")] = []byte(oldAddr)
+	assert.NoError(t, h.VerifyParticipant(oldAddr))
+
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Bob", oldAddr, "bobhash", "UK", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, oldAddr))
+	bob, err := h.GetParticipant(oldAddr)
+	assert.NoError(t, err)
+	tokenID := bob.TokenIDs[0]
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, "", tokenID, "bobhash", "50")
+	assert.NoError(t, err)
+
+	// Participants registered with derived addresses are left alone
+	h.SetIdentity("carol-id", "Org1MSP", "token_owner")
+	carolAddr, err := h.CreateParticipant("Carol", "carolpass", "FR")
+	assert.NoError(t, err)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = migrateNetworkAddresses(h)
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	moved, err := migrateNetworkAddresses(h)
+	assert.NoError(t, err)
+	assert.Len(t, moved, 1)
+	newAddr := moved[oldAddr]
+	assert.NotEmpty(t, newAddr)
+	assert.NotContains(t, moved, carolAddr)
+
+	assert.Nil(t, h.Stub.State[oldAddr])
+	assert.Nil(t, h.Stub.State[tokenRequestHistoryKey(oldAddr, 1)])
+	p, err := h.GetParticipant(newAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", p.Name)
+	assert.Equal(t, []string{tokenID}, p.TokenIDs)
+
+	request, err := h.GetFiledTokenRequest(newAddr, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, newAddr, request.NetworkAddr)
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, newAddr, token.Owner)
+
+	mint, err := h.GetMintRequest(mintReqID)
+	assert.NoError(t, err)
+	assert.Equal(t, newAddr, mint.RequestedBy)
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mint.RequestID))
+
+	// Bob keeps working through his bound identity
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	wallet, err := h.Contract.GetWalletInfo(h.Ctx, "", tokenID, "bobhash")
+	assert.NoError(t, err)
+	assert.Equal(t, newAddr, wallet["networkAddress"])
+	assert.Equal(t, Amount("50"), wallet["mintedCoins"])
+
+	h.SetAsAdmin()
+	moved, err = migrateNetworkAddresses(h)
+	assert.NoError(t, err)
+	assert.Empty(t, moved)
+}
+
+// addLegacyTokenOwner stores a participant under its old name-derived address and gives it a token.
+func addLegacyTokenOwner(t *testing.T, h *TestHelper, name, clientID string) (string, string) {
+	h.SetAsAdmin()
+	addr := legacyNetworkAddress(name)
+	pb, _ := json.Marshal(Participant{Name: name, NetworkAddress: addr, ClientID: clientID, PasswordHash: "hash", Country: "UK"})
+	h.Stub.State[addr] = pb
+	h.Stub.State[participantIDKey(clientID)] = []byte(addr)
+	assert.NoError(t, h.VerifyParticipant(addr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, name, addr, "hash", "UK", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, addr))
+	p, err := h.GetParticipant(addr)
+	assert.NoError(t, err)
+	return addr, p.TokenIDs[0]
+}
+
+func TestMigrateNetworkAddressesRekeysOwnershipRecords(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	bobAddr, bobToken := addLegacyTokenOwner(t, h, "Bob", "bob-id")
+	daveAddr, daveToken := addLegacyTokenOwner(t, h, "Dave", "dave-id")
+	h.SetIdentity("carol-id", "Org1MSP", "token_owner")
+	carolAddr, err := h.CreateParticipant("Carol", "carolpass", "FR")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.VerifyParticipant(carolAddr))
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	otID, err := h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, bobToken, daveAddr)
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	h.NewTx()
+	rev, err := h.Contract.RevokeToken(h.Ctx, daveToken, revokeSettle, "inactive")
+	assert.NoError(t, err)
+
+	moved, err := migrateNetworkAddresses(h)
+	assert.NoError(t, err)
+	assert.Len(t, moved, 2)
+
+	var ot TokenOwnershipTransfer
+	assert.NoError(t, json.Unmarshal(h.Stub.State[otID], &ot))
+	assert.Equal(t, moved[bobAddr], ot.FromOwner)
+	assert.Equal(t, moved[daveAddr], ot.ToOwner)
+	var stored TokenRevocation
+	assert.NoError(t, json.Unmarshal(h.Stub.State[rev.RevocationID], &stored))
+	assert.Equal(t, moved[daveAddr], stored.PreviousOwner)
+
+	// Dave accepts the transfer under his new address
+	h.SetIdentity("dave-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.AcceptTokenOwnershipTransfer(h.Ctx, otID))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParticipantOnboardingLifecycle(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, statusRegistered, p.Status)
+
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not verified")
+
+	// Only admins move participants through the lifecycle
+	err = h.Contract.VerifyParticipant(h.Ctx, netAddr)
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingRegistrations(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "Alice", pending[0].Name)
+
+	assert.NoError(t, h.Contract.StartParticipantReview(h.Ctx, netAddr))
+	assert.NoError(t, h.Contract.VerifyParticipant(h.Ctx, netAddr))
+	pending, err = h.Contract.GetPendingRegistrations(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+
+	// Suspension blocks token requests and minting until the participant is verified again
+	h.SetAsAdmin()
+	err = h.Contract.SuspendParticipant(h.Ctx, netAddr, "")
+	assert.Error(t, err)
+	assert.NoError(t, h.Contract.SuspendParticipant(h.Ctx, netAddr, "expired documents"))
+	err = h.Contract.ApproveTokenRequest(h.Ctx, netAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status SUSPENDED")
+
+	assert.NoError(t, h.Contract.VerifyParticipant(h.Ctx, netAddr))
+	assert.NoError(t, h.Contract.CloseParticipant(h.Ctx, netAddr, "account closed"))
+	err = h.Contract.VerifyParticipant(h.Ctx, netAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot move participant from CLOSED")
+}
+
+func TestRejectParticipant(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	err = h.Contract.RejectParticipant(h.Ctx, netAddr, "")
+	assert.Error(t, err)
+	assert.NoError(t, h.Contract.RejectParticipant(h.Ctx, netAddr, "documents do not match"))
+
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, statusRejected, p.Status)
+	assert.Equal(t, "documents do not match", p.StatusReason)
+
+	err = h.Contract.VerifyParticipant(h.Ctx, netAddr)
+	assert.Error(t, err)
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+}
+
+func TestLegacyParticipantStatus(t *testing.T) {
+	approved := Participant{NetworkAddress: "addr1", Approved: true}
+	assert.Equal(t, statusVerified, approved.onboardingStatus())
+
+	unapproved := Participant{NetworkAddress: "addr2"}
+	assert.Equal(t, statusRegistered, unapproved.onboardingStatus())
+
+	// Legacy records without status are listed for review
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	pb, _ := json.Marshal(Participant{Name: "Bob", NetworkAddress: "addrbob", PasswordHash: "bobhash", Country: "UK"})
+	h.Stub.State["addrbob"] = pb
+	pending, err := h.Contract.GetPendingRegistrations(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParticipantPIIKeptInCollection(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+
+	// The public record carries no PII, only the hash of the private record
+	var public Participant
+	assert.NoError(t, json.Unmarshal(h.Stub.State[netAddr], &public))
+	assert.Empty(t, public.Name)
+	assert.Empty(t, public.Country)
+	assert.Nil(t, public.Credential)
+	assert.NotEmpty(t, public.PrivateDataHash)
+	assert.NotContains(t, string(h.Stub.State[netAddr]), "Alice")
+	assert.Contains(t, string(h.Stub.PrivateState[piiCollection][netAddr]), "Alice")
+
+	p, err := h.Contract.GetParticipantDetails(h.Ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", p.Name)
+	assert.Equal(t, "USA", p.Country)
+
+	// Details are checked against the private record
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err = h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.True(t, p.Approved)
+	assert.Equal(t, "Alice", p.Name)
+	assert.NotContains(t, string(h.Stub.State[netAddr]), "Alice")
+}
+
+func TestPrivateDataHashMismatch(t *testing.T) {
+	h := NewTestHelper()
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	h.Stub.PrivateState[piiCollection][netAddr] = []byte(`{"name":"Mallory","country":"USA"}`)
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "", "pass123")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match its public hash")
+
+	delete(h.Stub.PrivateState[piiCollection], netAddr)
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "", "pass123")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not available on this peer")
+}
+
+func TestGetParticipantDetailsAccess(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.GetParticipantDetails(h.Ctx, netAddr)
+	assert.Error(t, err)
+
+	h.SetAsAdmin()
+	p, err := h.Contract.GetParticipantDetails(h.Ctx, netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", p.Name)
+}
+
+func TestMigratePrivateData(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	legacy := Participant{Name: "Bob", NetworkAddress: "addrbob", ClientID: "test-client-id", PasswordHash: "bobhash", Country: "UK"}
+	pb, _ := json.Marshal(legacy)
+	h.Stub.State["addrbob"] = pb
+
+	count, err := h.Contract.MigratePrivateData(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NotContains(t, string(h.Stub.State["addrbob"]), "Bob")
+	assert.NotContains(t, string(h.Stub.State["addrbob"]), "bobhash")
+
+	// Migrated records keep authenticating with the same details
+	assert.NoError(t, h.VerifyParticipant("addrbob"))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Bob", "addrbob", "bobhash", "UK", "123456", ""))
+
+	count, err = h.Contract.MigratePrivateData(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestReturnedParticipantsCarryNoSecrets(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	pb, _ := json.Marshal(Participant{Name: "Bob", NetworkAddress: "addrbob", PasswordHash: "bobhash", Country: "UK"})
+	h.Stub.State["addrbob"] = pb
+
+	p, err := h.Contract.GetParticipantDetails(h.Ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", p.Name)
+	assert.Nil(t, p.Credential)
+	assert.Empty(t, p.PasswordHash)
+
+	h.SetAsAdmin()
+	p, err = h.Contract.GetParticipantDetails(h.Ctx, "addrbob")
+	assert.NoError(t, err)
+	assert.Empty(t, p.PasswordHash)
+
+	pending, err := h.Contract.GetPendingRegistrations(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	for _, p := range pending {
+		assert.Nil(t, p.Credential, p.NetworkAddress)
+		assert.Empty(t, p.PasswordHash, p.NetworkAddress)
+	}
+
+	// The secrets are only hidden from callers, authentication still works
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+}
@@ -23,9 +23,10 @@ func TestQuickFunctionTest(t *testing.T) {
 	p, err := h.GetParticipant(netAddr)
 	assert.NoError(t, err)
 	assert.Equal(t, "Alice", p.Name)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
 
 	// 2. Request a token
-	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456")
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
 	assert.NoError(t, err)
 
 	// Verify request was created
@@ -40,27 +41,27 @@ func TestQuickFunctionTest(t *testing.T) {
 
 	// 4. Check participant got token
 	p, _ = h.GetParticipant(netAddr)
-	assert.NotEmpty(t, p.TokenID)
+	assert.Len(t, p.TokenIDs, 1)
 	assert.True(t, p.Approved)
 
 	// 5. Try to mint coins
-	mintReqID := "mintrequest_" + p.TokenID + "_" + netAddr
-	err = h.Contract.RequestMintCoins(h.Ctx, netAddr, "pass123", 100)
+	tokenID := p.TokenIDs[0]
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "100")
 	assert.NoError(t, err)
 
 	// Verify mint request
 	mr, err := h.GetMintRequest(mintReqID)
 	assert.NoError(t, err)
-	assert.Equal(t, 100, mr.Amount)
+	assert.Equal(t, Amount("100"), mr.Amount)
 
 	// 6. Approve mint request
 	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
 	assert.NoError(t, err)
 
 	// Check token was minted
-	token, err := h.GetToken(p.TokenID)
+	token, err := h.GetToken(tokenID)
 	assert.NoError(t, err)
-	assert.Equal(t, 100, token.Minted)
+	assert.Equal(t, Amount("100"), token.Minted)
 }
 
 // Example of testing just one function with different inputs
@@ -74,11 +75,12 @@ func TestTryDifferentInputs(t *testing.T) {
 
 	// Test case 2: Try duplicate registration
 	_, err = h.CreateParticipant("Bob", "pass456", "Canada")
-	assert.Error(t, err) // Should fail as participant exists
+	assert.Error(t, err) // Should fail as the caller is already registered
 
-	// Test case 3: Different country
+	// Test case 3: Same details from another identity
+	h.SetIdentity("bob-uk-id", "Org1MSP", "token_owner")
 	addr3, err := h.CreateParticipant("Bob", "pass456", "UK")
-	assert.NoError(t, err) // Should work as it's different details
+	assert.NoError(t, err) // Should work as addresses come from the caller's certificate
 	assert.NotEqual(t, addr1, addr3)
 
 	// Get and verify details
@@ -29,6 +29,7 @@ func TestRequestTokenRequest_Variations(t *testing.T) {
 					PasswordHash:   "pass123hash",
 					Country:        "USA",
 					Approved:       false,
+					Status:         statusVerified,
 					TokenID:        "",
 				}
 				pb, _ := json.Marshal(p)
@@ -73,6 +74,26 @@ func TestRequestTokenRequest_Variations(t *testing.T) {
 			expectError:   true,
 			errorContains: "details do not match",
 		},
+		{
+			name: "participant not yet verified",
+			setupState: func(stub *mockStub) {
+				p := Participant{
+					Name:           "Erin",
+					NetworkAddress: "addr202",
+					PasswordHash:   "pass202",
+					Country:        "Spain",
+					Status:         statusRegistered,
+				}
+				pb, _ := json.Marshal(p)
+				stub.State["addr202"] = pb
+			},
+			inputName:     "Erin",
+			inputAddr:     "addr202",
+			inputPass:     "pass202",
+			inputCountry:  "Spain",
+			expectError:   true,
+			errorContains: "not verified",
+		},
 		{
 			name: "already has pending request",
 			setupState: func(stub *mockStub) {
@@ -83,6 +104,7 @@ func TestRequestTokenRequest_Variations(t *testing.T) {
 					PasswordHash:   "pass101",
 					Country:        "France",
 					Approved:       false,
+					Status:         statusVerified,
 					TokenID:        "",
 				}
 				pb, _ := json.Marshal(p)
@@ -98,18 +120,19 @@ func TestRequestTokenRequest_Variations(t *testing.T) {
 				rb, _ := json.Marshal(req)
 				stub.State["tokenrequest_addr101"] = rb
 			},
-			inputName:    "Dave",
-			inputAddr:    "addr101",
-			inputPass:    "pass101",
-			inputCountry: "France",
-			expectError:  false, // Current logic allows multiple requests
+			inputName:     "Dave",
+			inputAddr:     "addr101",
+			inputPass:     "pass101",
+			inputCountry:  "France",
+			expectError:   true, // An open request is never overwritten
+			errorContains: "already pending",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cc := new(SmartContract)
-			stub := &mockStub{State: make(map[string][]byte)}
+			stub := &mockStub{State: map[string][]byte{amountsMigratedKey: []byte("true")}}
 
 			// Setup test state
 			tt.setupState(stub)
@@ -120,7 +143,7 @@ func TestRequestTokenRequest_Variations(t *testing.T) {
 			}
 
 			// Execute the request
-			err := cc.RequestTokenRequest(ctx, tt.inputName, tt.inputAddr, tt.inputPass, tt.inputCountry, "123456") // Default pincode for existing tests
+			err := cc.RequestTokenRequest(ctx, tt.inputName, tt.inputAddr, tt.inputPass, tt.inputCountry, "123456", "") // Default pincode for existing tests
 
 			// Verify expectations
 			if tt.expectError {
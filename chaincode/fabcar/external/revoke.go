@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Settlement modes accepted by RevokeToken
+const (
+	// revokeSettle zeroes customer balances and records a settlement for off-chain payout
+	revokeSettle = "SETTLE"
+	// revokeFreeze keeps customer balances on the ledger but blocks any further use
+	revokeFreeze = "FREEZE"
+
+	revocationKeyPrefix = "revocation_"
+)
+
+// TokenRevocation records what RevokeToken did to a token
+type TokenRevocation struct {
+	RevocationID      string `json:"revocation_id"`
+	TokenID           string `json:"token_id"`
+	PreviousOwner     string `json:"previous_owner"`
+	Mode              string `json:"mode"` // SETTLE or FREEZE
+	Reason            string `json:"reason"`
+	RevokedBy         string `json:"revoked_by"`
+	MintedForfeited   Amount `json:"minted_forfeited"` // owner's unissued coins at revocation
+	CancelledRequests int    `json:"cancelled_requests"`
+}
+
+// RevokeToken takes a token back from its owner and returns it to the pool, or to the first
+// waitlisted participant. Pending mint requests on the token are cancelled. This chaincode
+// keeps no customer balances, so mode is only checked, for parity with the customer-aware
+// contract.
+func (s *SmartContract) RevokeToken(ctx contractapi.TransactionContextInterface, tokenID, mode, reason string) (*TokenRevocation, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if mode != revokeSettle && mode != revokeFreeze {
+		return nil, fmt.Errorf("settlement mode must be %s or %s", revokeSettle, revokeFreeze)
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token.Owner == "" {
+		return nil, fmt.Errorf("token is not assigned")
+	}
+	adminID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, err
+	}
+
+	rev := &TokenRevocation{
+		RevocationID:    fmt.Sprintf("%s%s_%s", revocationKeyPrefix, tokenID, ctx.GetStub().GetTxID()),
+		TokenID:         tokenID,
+		PreviousOwner:   token.Owner,
+		Mode:            mode,
+		Reason:          reason,
+		RevokedBy:       adminID,
+		MintedForfeited: token.Minted,
+	}
+	if err := s.cancelTokenRequests(ctx, rev); err != nil {
+		return nil, err
+	}
+
+	owner, err := s.getParticipant(ctx, token.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if owner.ownsToken(tokenID) {
+		owner.removeOwnedToken(tokenID)
+		if err := s.putParticipant(ctx, owner, false); err != nil {
+			return nil, err
+		}
+	}
+	r, err := s.approvedTokenRequest(ctx, token.Owner, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if r != nil {
+		r.Status = "REVOKED"
+		if err := s.putTokenRequest(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+
+	if token.Metadata != nil {
+		if holder, err := symbolOwner(ctx, token.Metadata.Symbol); err == nil && holder == tokenID {
+			if err := ctx.GetStub().DelState(symbolKey(token.Metadata.Symbol)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	token.Owner = ""
+	token.Available = true
+	token.TotalMinted = token.totalMinted()
+	token.Minted = zeroAmount
+	token.Metadata = nil
+	token.PendingMetadata = nil
+	token.MetadataRejection = ""
+	if err := s.putToken(ctx, token); err != nil {
+		return nil, err
+	}
+	if err := s.addTokenToPool(ctx, tokenID); err != nil {
+		return nil, err
+	}
+	if err := s.assignFromWaitlist(ctx, tokenID); err != nil {
+		return nil, err
+	}
+
+	rb, err := json.Marshal(rev)
+	if err != nil {
+		return nil, err
+	}
+	return rev, ctx.GetStub().PutState(rev.RevocationID, rb)
+}
+
+// cancelTokenRequests cancels the requests still pending on rev.TokenID, counting each into rev
+func (s *SmartContract) cancelTokenRequests(ctx contractapi.TransactionContextInterface, rev *TokenRevocation) error {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return err
+	}
+	var records []keyValue
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		records = append(records, keyValue{kv.Key, kv.Value})
+	}
+	iter.Close()
+
+	for _, kv := range records {
+		if !strings.HasPrefix(kv.Key, "mintrequest_") {
+			continue
+		}
+		var r MintRequest
+		if json.Unmarshal(kv.Value, &r) != nil || r.TokenID != rev.TokenID || r.status() != mintRequestPending {
+			continue
+		}
+		r.close(mintRequestRejected, "token revoked")
+		if err := s.putMintRequest(ctx, &r); err != nil {
+			return err
+		}
+		rev.CancelledRequests++
+	}
+	return nil
+}
+
+// GetTokenRevocations lists the revocations recorded for tokenID (admin)
+func (s *SmartContract) GetTokenRevocations(ctx contractapi.TransactionContextInterface, tokenID string) ([]TokenRevocation, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var revs []TokenRevocation
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, revocationKeyPrefix+tokenID+"_") {
+			var r TokenRevocation
+			if err := json.Unmarshal(kv.Value, &r); err == nil && r.TokenID == tokenID {
+				revs = append(revs, r)
+			}
+		}
+	}
+	return revs, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// decimals is the number of fraction digits of the token's coins, set through its metadata
+func (t *Token) decimals() int {
+	if t.Metadata != nil {
+		return t.Metadata.Decimals
+	}
+	return 0
+}
+
+// totalMinted is the supply ever minted on the token. Tokens minted before TotalMinted was
+// tracked start from their current Minted, the best lower bound left on the ledger.
+func (t *Token) totalMinted() Amount {
+	if t.TotalMinted.cmp(t.Minted) < 0 {
+		return t.Minted
+	}
+	return t.TotalMinted
+}
+
+// checkMintAllowed refuses a mint of amount that breaks the token's caps; zero caps are unlimited
+func (t *Token) checkMintAllowed(amount Amount) error {
+	d := t.decimals()
+	if amount.isZero() {
+		return fmt.Errorf("mint amount must be positive")
+	}
+	if !t.MaxMintPerRequest.isZero() && amount.cmp(t.MaxMintPerRequest) > 0 {
+		return fmt.Errorf("mint amount %s exceeds per-request maximum %s", amount.format(d), t.MaxMintPerRequest.format(d))
+	}
+	total, err := t.totalMinted().add(amount)
+	if err != nil {
+		return err
+	}
+	if !t.MaxSupply.isZero() && total.cmp(t.MaxSupply) > 0 {
+		return fmt.Errorf("mint would exceed maximum supply: minted %s of %s, requested %s", t.totalMinted().format(d), t.MaxSupply.format(d), amount.format(d))
+	}
+	return nil
+}
+
+// SetTokenSupplyCap sets the maximum supply ever minted on a token and the maximum of a single
+// mint request, both in coins; zero removes a cap. The supply cap cannot go below what was
+// already minted.
+func (s *SmartContract) SetTokenSupplyCap(ctx contractapi.TransactionContextInterface, tokenID, maxSupply, maxMintPerRequest string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	d := token.decimals()
+	supply, err := parseAmount(maxSupply, d)
+	if err != nil {
+		return err
+	}
+	perRequest, err := parseAmount(maxMintPerRequest, d)
+	if err != nil {
+		return err
+	}
+	if !supply.isZero() && supply.cmp(token.totalMinted()) < 0 {
+		return fmt.Errorf("maximum supply %s is below the %s already minted", supply.format(d), token.totalMinted().format(d))
+	}
+
+	token.TotalMinted = token.totalMinted()
+	token.MaxSupply = supply
+	token.MaxMintPerRequest = perRequest
+	return s.putToken(ctx, token)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenSupplyCaps(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	_, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "-5")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid amount")
+	_, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "0")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be positive")
+	err = h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "100", "60")
+	assert.Error(t, err, "only admins set caps")
+
+	h.SetAsAdmin()
+	assert.Error(t, h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "-1", "0"))
+	assert.NoError(t, h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "100", "60"))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "70")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "per-request maximum")
+
+	for _, amount := range []string{"60", "40"} {
+		h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+		h.NewTx()
+		mintReqID, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", amount)
+		assert.NoError(t, err)
+		h.SetAsAdmin()
+		assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	}
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	mintReqID, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "1")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum supply")
+	err = h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "50", "0")
+	assert.Error(t, err, "cap below what was minted")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	wallet, err := h.Contract.GetWalletInfo(h.Ctx, netAddr, tokenID, "pass123")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("100"), wallet["totalMinted"])
+	assert.Equal(t, Amount("100"), wallet["maxSupply"])
+}
+
+func TestLegacyTokenTotalMinted(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	// Tokens minted before the total was tracked count their current balance
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	token.Minted = Amount("80")
+	tb, _ := json.Marshal(token)
+	h.Stub.State[tokenID] = tb
+
+	h.SetAsAdmin()
+	err = h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "50", "0")
+	assert.Error(t, err)
+	assert.NoError(t, h.Contract.SetTokenSupplyCap(h.Ctx, tokenID, "90", "0"))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "20")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "minted 80 of 90")
+}
+
+func TestRevokeKeepsLegacyTotalMinted(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	token.Minted = Amount("80")
+	tb, _ := json.Marshal(token)
+	h.Stub.State[tokenID] = tb
+
+	// The lazily derived total is saved before revocation clears the owner's coins
+	h.SetAsAdmin()
+	_, err = h.Contract.RevokeToken(h.Ctx, tokenID, revokeSettle, "fraud")
+	assert.NoError(t, err)
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, zeroAmount, token.Minted)
+	assert.Equal(t, Amount("80"), token.TotalMinted)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTokenOwner registers, verifies and assigns a token to a participant acting as identity id
+func setupTokenOwner(t *testing.T, h *TestHelper, id, name string) (string, string) {
+	h.SetIdentity(id, "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant(name, "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, name, netAddr, "pass123", "USA", "123456", ""))
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	h.SetIdentity(id, "Org1MSP", "token_owner")
+	return netAddr, p.TokenIDs[len(p.TokenIDs)-1]
+}
+
+func TestUpdateTokenMetadataReview(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	err := h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "a", 2, "", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token symbol")
+	err = h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "ALC", 2, "", "", "http://example.com/terms")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "https")
+
+	// A new symbol waits for admin review
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "alc", 2, "Community coin", "", "https://example.com/terms"))
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Nil(t, token.Metadata)
+	assert.Equal(t, "ALC", token.PendingMetadata.Symbol)
+
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingTokenMetadata(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.NoError(t, h.Contract.ApproveTokenMetadata(h.Ctx, tokenID))
+
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice Coin", token.Metadata.Name)
+	assert.Nil(t, token.PendingMetadata)
+
+	// Keeping the symbol applies at once
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin v2", "ALC", 2, "", "", ""))
+	token, err = h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice Coin v2", token.Metadata.Name)
+}
+
+func TestTokenSymbolUniqueness(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, aliceToken := setupTokenOwner(t, h, "alice-id", "Alice")
+	_, bobToken := setupTokenOwner(t, h, "bob-id", "Bob")
+
+	// Bob can only edit his own token
+	err := h.Contract.UpdateTokenMetadata(h.Ctx, aliceToken, "Bob Coin", "BOB", 0, "", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+
+	// Both ask for the same symbol; the second approval is refused
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, bobToken, "Bob Coin", "COIN", 0, "", "", ""))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, aliceToken, "Alice Coin", "COIN", 0, "", "", ""))
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenMetadata(h.Ctx, bobToken))
+	err = h.Contract.ApproveTokenMetadata(h.Ctx, aliceToken)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already in use")
+	assert.NoError(t, h.Contract.RejectTokenMetadata(h.Ctx, aliceToken, "symbol taken"))
+
+	token, err := h.GetToken(aliceToken)
+	assert.NoError(t, err)
+	assert.Nil(t, token.PendingMetadata)
+	assert.Equal(t, "symbol taken", token.MetadataRejection)
+
+	// Taken symbols are refused up front
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	err = h.Contract.UpdateTokenMetadata(h.Ctx, aliceToken, "Alice Coin", "COIN", 0, "", "", "")
+	assert.Error(t, err)
+
+	// Renaming releases the old symbol
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, bobToken, "Bob Coin", "BOBC", 0, "", "", ""))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenMetadata(h.Ctx, bobToken))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, aliceToken, "Alice Coin", "COIN", 0, "", "", ""))
+}
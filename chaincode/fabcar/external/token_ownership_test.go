@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenOwnershipTransfer(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	aliceAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, aliceAddr, tokenID, "pass123", "100")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	bobAddr, err := h.CreateParticipant("Bob", "pass456", "UK")
+	assert.NoError(t, err)
+
+	// Only the owner proposes
+	_, err = h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, tokenID, bobAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	transferID, err := h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, tokenID, bobAddr)
+	assert.NoError(t, err)
+	_, err = h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, tokenID, bobAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "open ownership transfer")
+
+	// The receiver must accept, and must be verified to do so
+	err = h.Contract.AcceptTokenOwnershipTransfer(h.Ctx, transferID)
+	assert.Error(t, err)
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	err = h.Contract.AcceptTokenOwnershipTransfer(h.Ctx, transferID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not verified")
+	assert.NoError(t, h.VerifyParticipant(bobAddr))
+
+	h.SetAsAdmin()
+	err = h.Contract.ApproveTokenOwnershipTransfer(h.Ctx, transferID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not accepted")
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.AcceptTokenOwnershipTransfer(h.Ctx, transferID))
+
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingTokenOwnershipTransfers(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.NoError(t, h.Contract.ApproveTokenOwnershipTransfer(h.Ctx, transferID))
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, bobAddr, token.Owner)
+	assert.Equal(t, Amount("100"), token.Minted)
+
+	alice, err := h.GetParticipant(aliceAddr)
+	assert.NoError(t, err)
+	assert.Empty(t, alice.ownedTokenIDs())
+	assert.False(t, alice.Approved)
+	bob, err := h.GetParticipant(bobAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{tokenID}, bob.TokenIDs)
+
+	// The new owner mints; the previous owner no longer can
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	_, err = h.Contract.RequestMintCoins(h.Ctx, bobAddr, tokenID, "pass456", "10")
+	assert.NoError(t, err)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.RequestMintCoins(h.Ctx, aliceAddr, tokenID, "pass123", "10")
+	assert.Error(t, err)
+}
+
+func TestRejectTokenOwnershipTransfer(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	bobAddr, _ := setupTokenOwner(t, h, "bob-id", "Bob")
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	transferID, err := h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, tokenID, bobAddr)
+	assert.NoError(t, err)
+
+	h.SetIdentity("carol-id", "Org1MSP", "token_owner")
+	_, err = h.CreateParticipant("Carol", "pass789", "USA")
+	assert.NoError(t, err)
+	err = h.Contract.RejectTokenOwnershipTransfer(h.Ctx, transferID, "not mine")
+	assert.Error(t, err)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RejectTokenOwnershipTransfer(h.Ctx, transferID, "declined"))
+	err = h.Contract.AcceptTokenOwnershipTransfer(h.Ctx, transferID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "REJECTED")
+
+	// A new proposal may follow a closed one
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.ProposeTokenOwnershipTransfer(h.Ctx, tokenID, bobAddr)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitLedgerIsIdempotent(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+
+	assert.NoError(t, h.InitLedgerWithTokens())
+	token, err := h.GetToken(p.TokenIDs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, netAddr, token.Owner)
+	assert.False(t, token.Available)
+
+	_, err = h.GetToken("token_26")
+	assert.Error(t, err)
+}
+
+func TestCreateTokens(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	assert.NoError(t, h.Contract.CreateToken(h.Ctx, "token_27"))
+	err := h.Contract.CreateToken(h.Ctx, "token_27")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	err = h.Contract.CreateToken(h.Ctx, "gold")
+	assert.Error(t, err)
+
+	// Generated IDs continue the sequence and skip IDs already taken
+	created, err := h.Contract.CreateTokens(h.Ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token_26", "token_28", "token_29"}, created)
+
+	_, err = h.Contract.CreateTokens(h.Ctx, 0)
+	assert.Error(t, err)
+	_, err = h.Contract.CreateTokens(h.Ctx, maxTokensPerCall+1)
+	assert.Error(t, err)
+
+	h.SetIdentity("someone", "Org1MSP", "token_owner")
+	_, err = h.Contract.CreateTokens(h.Ctx, 1)
+	assert.Error(t, err)
+}
+
+func TestTokenPoolAssignment(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	// Drain the initial pool and refill it with a single token
+	for n := 0; n < initialTokens; n++ {
+		tid, err := h.Contract.findAvailableToken(h.Ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, h.Contract.removeTokenFromPool(h.Ctx, tid))
+	}
+	tid, err := h.Contract.findAvailableToken(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, tid)
+
+	assert.NoError(t, h.Contract.CreateToken(h.Ctx, "token_extra"))
+	tid, err = h.Contract.findAvailableToken(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_extra", tid)
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+
+	tid, err = h.Contract.findAvailableToken(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, tid)
+}
+
+func TestLegacyLedgerPoolRebuild(t *testing.T) {
+	h := NewTestHelper()
+	for i, available := range []bool{true, false, true} {
+		token := Token{TokenID: "token_" + string(rune('1'+i)), Available: available}
+		b, _ := json.Marshal(token)
+		h.Stub.State[token.TokenID] = b
+	}
+
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, err := h.GetToken("token_4")
+	assert.Error(t, err, "existing ledgers get no fresh tokens")
+
+	created, err := h.Contract.CreateTokens(h.Ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token_4"}, created)
+
+	var pooled []string
+	for tid, _ := h.Contract.findAvailableToken(h.Ctx); tid != ""; tid, _ = h.Contract.findAvailableToken(h.Ctx) {
+		pooled = append(pooled, tid)
+		assert.NoError(t, h.Contract.removeTokenFromPool(h.Ctx, tid))
+	}
+	assert.Equal(t, []string{"token_1", "token_3", "token_4"}, pooled)
+}
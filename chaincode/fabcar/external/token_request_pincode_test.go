@@ -1,14 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRequestTokenWithPincode(t *testing.T) {
-	// Create our test helper
+	// Create our test helper; the admin registry is needed to verify participants
 	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
 
 	// Test cases
 	tests := []struct {
@@ -50,12 +52,14 @@ func TestRequestTokenWithPincode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Register a new participant for each test
+			// Register a new participant for each test, each with its own identity
+			h.SetIdentity(tt.participantName+"-id", "Org1MSP", "token_owner")
 			netAddr, err := h.CreateParticipant(tt.participantName, tt.password, tt.country)
 			assert.NoError(t, err)
+			assert.NoError(t, h.VerifyParticipant(netAddr))
 
 			// Try to request token with pincode
-			err = h.Contract.RequestTokenRequest(h.Ctx, tt.participantName, netAddr, tt.password, tt.country, tt.pincode)
+			err = h.Contract.RequestTokenRequest(h.Ctx, tt.participantName, netAddr, tt.password, tt.country, tt.pincode, "")
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -63,10 +67,11 @@ func TestRequestTokenWithPincode(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 
-				// Verify the request was stored with correct pincode
+				// Verify the request was stored with a hash of the pincode only
 				request, err := h.GetTokenRequest(netAddr)
 				assert.NoError(t, err)
-				assert.Equal(t, tt.pincode, request.Pincode)
+				assert.Empty(t, request.Pincode)
+				assert.True(t, request.PincodeCredential.matches(tt.pincode))
 				assert.Equal(t, "PENDING", request.Status)
 			}
 		})
@@ -77,6 +82,10 @@ func TestRequestTokenWithPincode(t *testing.T) {
 func TestTokenRequestFlowWithPincode(t *testing.T) {
 	h := NewTestHelper()
 
+	// Initialize tokens and admin registry
+	err := h.InitLedgerWithTokens()
+	assert.NoError(t, err)
+
 	// 1. Create participant
 	name := "Dave"
 	pass := "pass123"
@@ -85,15 +94,17 @@ func TestTokenRequestFlowWithPincode(t *testing.T) {
 
 	netAddr, err := h.CreateParticipant(name, pass, country)
 	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
 
 	// 2. Request token with pincode
-	err = h.Contract.RequestTokenRequest(h.Ctx, name, netAddr, pass, country, pincode)
+	err = h.Contract.RequestTokenRequest(h.Ctx, name, netAddr, pass, country, pincode, "")
 	assert.NoError(t, err)
 
 	// 3. Verify request details
 	request, err := h.GetTokenRequest(netAddr)
 	assert.NoError(t, err)
-	assert.Equal(t, pincode, request.Pincode)
+	assert.True(t, request.PincodeCredential.matches(pincode))
+	assert.NotContains(t, string(h.Stub.State["tokenrequest_"+netAddr]), pincode)
 	assert.Equal(t, "PENDING", request.Status)
 
 	// 4. Approve request as admin
@@ -102,8 +113,37 @@ func TestTokenRequestFlowWithPincode(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 5. Verify final state
-	request, err = h.GetTokenRequest(netAddr)
+	_, err = h.GetTokenRequest(netAddr)
+	assert.Error(t, err, "approved requests move to the history")
+	request, err = h.GetFiledTokenRequest(netAddr, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, "APPROVED", request.Status)
 	assert.NotEmpty(t, request.TokenID)
 }
+
+func TestMigratePincodes(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	h.SetIdentity("dave-id", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Dave", "pass123", "India")
+	assert.NoError(t, err)
+
+	legacy := TokenRequest{RequestID: "tokenrequest_" + netAddr, NetworkAddr: netAddr, Status: "PENDING", Pincode: "999999"}
+	b, _ := json.Marshal(legacy)
+	h.Stub.State[legacy.RequestID] = b
+
+	_, err = h.Contract.MigratePincodes(h.Ctx)
+	assert.Error(t, err, "admin only")
+	h.SetAsAdmin()
+	migrated, err := h.Contract.MigratePincodes(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.NotContains(t, string(h.Stub.State[legacy.RequestID]), "999999")
+	request, err := h.GetTokenRequest(netAddr)
+	assert.NoError(t, err)
+	assert.True(t, request.PincodeCredential.matches("999999"))
+
+	migrated, err = h.Contract.MigratePincodes(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
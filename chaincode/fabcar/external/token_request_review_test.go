@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRequestRejectCancelAndHistory(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+
+	// An open request is never overwritten
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "654321", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already pending")
+
+	// Only the participant cancels its request
+	h.SetIdentity("mallory-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.CancelTokenRequest(h.Ctx, netAddr))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.CancelTokenRequest(h.Ctx, ""))
+	r, err := h.GetTokenRequest(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "CANCELLED", r.Status)
+	assert.Error(t, h.Contract.CancelTokenRequest(h.Ctx, netAddr))
+
+	// Only admins reject, with a reason
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+	assert.Error(t, h.Contract.RejectTokenRequest(h.Ctx, netAddr, "incomplete"))
+	h.SetAsAdmin()
+	assert.Error(t, h.Contract.RejectTokenRequest(h.Ctx, netAddr, ""))
+	assert.NoError(t, h.Contract.RejectTokenRequest(h.Ctx, netAddr, "incomplete"))
+	err = h.Contract.ApproveTokenRequest(h.Ctx, netAddr)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already processed")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", ""))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+
+	// The approved request is filed in the history at once, leaving room for another
+	_, err = h.GetTokenRequest(netAddr)
+	assert.Error(t, err)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "second shop"))
+
+	history, err := h.Contract.GetTokenRequestHistory(h.Ctx, netAddr)
+	assert.NoError(t, err)
+	assert.Len(t, history, 4)
+	var statuses []string
+	for i, r := range history {
+		assert.Equal(t, i+1, r.Sequence)
+		statuses = append(statuses, r.Status)
+	}
+	assert.Equal(t, []string{"CANCELLED", "REJECTED", "APPROVED", "PENDING"}, statuses)
+	assert.Equal(t, "incomplete", history[1].Reason)
+	assert.NotEmpty(t, history[2].TokenID)
+	assert.Equal(t, "tokenrequest_"+netAddr, history[3].RequestID)
+
+	h.SetIdentity("mallory-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.GetTokenRequestHistory(h.Ctx, netAddr)
+	assert.Error(t, err)
+	h.SetAsAdmin()
+	history, err = h.Contract.GetTokenRequestHistory(h.Ctx, netAddr)
+	assert.NoError(t, err)
+	assert.Len(t, history, 4)
+}
+
+func TestRejectWaitlistedTokenRequest(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	for n := 0; n < initialTokens; n++ {
+		tid, err := h.Contract.findAvailableToken(h.Ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, h.Contract.removeTokenFromPool(h.Ctx, tid))
+	}
+	alice := requestToken(t, h, "alice-id", "Alice")
+	bob := requestToken(t, h, "bob-id", "Bob")
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, alice))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, bob))
+
+	assert.NoError(t, h.Contract.RejectTokenRequest(h.Ctx, alice, "duplicate business"))
+	list, err := h.Contract.GetWaitlist(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, bob, list[0].NetworkAddress)
+
+	assert.NoError(t, h.Contract.CreateToken(h.Ctx, "token_extra"))
+	token, err := h.GetToken("token_extra")
+	assert.NoError(t, err)
+	assert.Equal(t, bob, token.Owner)
+}
+
+func TestApprovedTokenRequestIsNotReplaced(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	// Requests approved before they were filed on approval stay current and are never reset
+	r, err := h.GetFiledTokenRequest(netAddr, 1)
+	assert.NoError(t, err)
+	r.RequestID = "tokenrequest_" + netAddr
+	r.Sequence = 0
+	rb, _ := json.Marshal(r)
+	h.Stub.State[r.RequestID] = rb
+	delete(h.Stub.State, tokenRequestHistoryKey(netAddr, 1))
+
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "second shop")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already approved")
+	current, err := h.GetTokenRequest(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "APPROVED", current.Status)
+
+	// Revocation still finds it
+	h.SetAsAdmin()
+	_, err = h.Contract.RevokeToken(h.Ctx, tokenID, revokeSettle, "fraud")
+	assert.NoError(t, err)
+	current, err = h.GetTokenRequest(netAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "REVOKED", current.Status)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevokeToken(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	netAddr, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "ALC", 2, "", "", ""))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenMetadata(h.Ctx, tokenID))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "1.00")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	mintReqID, err = h.Contract.RequestMintCoins(h.Ctx, netAddr, tokenID, "pass123", "0.50")
+	assert.NoError(t, err)
+
+	// Only admins revoke, and they must say how and why
+	_, err = h.Contract.RevokeToken(h.Ctx, tokenID, revokeSettle, "fraud")
+	assert.Error(t, err)
+	h.SetAsAdmin()
+	_, err = h.Contract.RevokeToken(h.Ctx, tokenID, "REFUND", "fraud")
+	assert.Error(t, err)
+	_, err = h.Contract.RevokeToken(h.Ctx, tokenID, revokeSettle, "")
+	assert.Error(t, err)
+
+	rev, err := h.Contract.RevokeToken(h.Ctx, tokenID, revokeSettle, "fraud")
+	assert.NoError(t, err)
+	assert.Equal(t, netAddr, rev.PreviousOwner)
+	assert.Equal(t, Amount("100"), rev.MintedForfeited)
+	assert.Equal(t, 1, rev.CancelledRequests)
+
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.True(t, token.Available)
+	assert.Empty(t, token.Owner)
+	assert.Equal(t, zeroAmount, token.Minted)
+	assert.Nil(t, token.Metadata)
+
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	assert.Empty(t, p.TokenIDs)
+	assert.False(t, p.Approved)
+	tr, err := h.GetFiledTokenRequest(netAddr, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "REVOKED", tr.Status)
+
+	// The rejected mint request can no longer be approved
+	pending, err := h.Contract.GetPendingMintRequests(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	err = h.Contract.ApproveMintRequest(h.Ctx, mintReqID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected: token revoked")
+
+	_, err = h.Contract.RevokeToken(h.Ctx, tokenID, revokeSettle, "fraud")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not assigned")
+
+	revs, err := h.Contract.GetTokenRevocations(h.Ctx, tokenID)
+	assert.NoError(t, err)
+	assert.Len(t, revs, 1)
+}
+
+func TestRevokedTokenIsReassigned(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	// Leave a single token in the pool so the revoked one is the only candidate
+	for n := 1; n < initialTokens; n++ {
+		tid, err := h.Contract.findAvailableToken(h.Ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, h.Contract.removeTokenFromPool(h.Ctx, tid))
+	}
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Alice Coin", "ALC", 2, "", "", ""))
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenMetadata(h.Ctx, tokenID))
+	_, err := h.Contract.RevokeToken(h.Ctx, tokenID, revokeFreeze, "non-compliant issuer")
+	assert.NoError(t, err)
+
+	bobAddr, bobToken := setupTokenOwner(t, h, "bob-id", "Bob")
+	assert.Equal(t, tokenID, bobToken)
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, bobAddr, token.Owner)
+
+	// The released symbol is free for the new owner
+	assert.NoError(t, h.Contract.UpdateTokenMetadata(h.Ctx, tokenID, "Bob Coin", "ALC", 0, "", "", ""))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+// requestToken registers and verifies a participant acting as identity id and submits its
+// token request, leaving the admin identity set
+func requestToken(t *testing.T, h *TestHelper, id, name string) string {
+	h.SetIdentity(id, "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant(name, "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, name, netAddr, "pass123", "USA", "123456", ""))
+	h.SetAsAdmin()
+	return netAddr
+}
+
+func TestTokenWaitlist(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	for n := 0; n < initialTokens; n++ {
+		tid, err := h.Contract.findAvailableToken(h.Ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, h.Contract.removeTokenFromPool(h.Ctx, tid))
+	}
+
+	alice := requestToken(t, h, "alice-id", "Alice")
+	bob := requestToken(t, h, "bob-id", "Bob")
+	carol := requestToken(t, h, "carol-id", "Carol")
+
+	// Approvals without a free token queue by transaction time, not approval call order
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000200}
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, bob))
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000100}
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, alice))
+	h.Stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1700000300}
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, carol))
+
+	r, err := h.GetTokenRequest(alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "WAITLISTED", r.Status)
+	assert.Empty(t, r.TokenID)
+	err = h.Contract.ApproveTokenRequest(h.Ctx, alice)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already processed")
+
+	list, err := h.Contract.GetWaitlist(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	assert.Equal(t, WaitlistEntry{Position: 1, NetworkAddress: alice, RequestID: "tokenrequest_" + alice, WaitlistedAt: "2023-11-14T22:15:00.000000000Z"}, list[0])
+	assert.Equal(t, bob, list[1].NetworkAddress)
+	assert.Equal(t, 2, list[1].Position)
+	assert.Equal(t, carol, list[2].NetworkAddress)
+
+	h.SetAsNonAdmin()
+	_, err = h.Contract.GetWaitlist(h.Ctx)
+	assert.Error(t, err)
+	h.SetAsAdmin()
+
+	// A new token goes straight to the head of the waitlist
+	assert.NoError(t, h.Contract.CreateToken(h.Ctx, "token_extra"))
+	token, err := h.GetToken("token_extra")
+	assert.NoError(t, err)
+	assert.Equal(t, alice, token.Owner)
+	assert.False(t, token.Available)
+	tid, err := h.Contract.findAvailableToken(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, tid)
+	r, err = h.GetFiledTokenRequest(alice, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "APPROVED", r.Status)
+	assert.Equal(t, "token_extra", r.TokenID)
+	p, err := h.GetParticipant(alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token_extra"}, p.TokenIDs)
+	assert.True(t, p.Approved)
+
+	// A frozen participant keeps its place but is passed over
+	assert.NoError(t, h.Contract.FreezeParticipant(h.Ctx, bob, freezeInvestigation))
+	_, err = h.Contract.RevokeToken(h.Ctx, "token_extra", revokeSettle, "fraud")
+	assert.NoError(t, err)
+	token, err = h.GetToken("token_extra")
+	assert.NoError(t, err)
+	assert.Equal(t, carol, token.Owner)
+
+	list, err = h.Contract.GetWaitlist(h.Ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, WaitlistEntry{Position: 1, NetworkAddress: bob, RequestID: "tokenrequest_" + bob, WaitlistedAt: "2023-11-14T22:16:40.000000000Z"}, list[0])
+
+	assert.NoError(t, h.Contract.UnfreezeParticipant(h.Ctx, bob))
+	assert.NoError(t, h.Contract.CreateToken(h.Ctx, "token_more"))
+	token, err = h.GetToken("token_more")
+	assert.NoError(t, err)
+	assert.Equal(t, bob, token.Owner)
+	list, err = h.Contract.GetWaitlist(h.Ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	// With nobody waiting, new tokens stay in the pool
+	assert.NoError(t, h.Contract.CreateToken(h.Ctx, "token_spare"))
+	tid, err = h.Contract.findAvailableToken(h.Ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_spare", tid)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsFromTransientMap(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	h.SetTransient(map[string]string{"password_hash": "pass123", "name": "Alice", "country": "USA"})
+	netAddr, err := h.Contract.SubmitRegistration(h.Ctx, "", "", "")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(h.Stub.State[netAddr]), "Alice")
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+
+	h.SetTransient(map[string]string{"password_hash": "pass123", "pincode": "654321", "name": "Alice", "country": "USA"})
+	err = h.Contract.RequestTokenRequest(h.Ctx, "", netAddr, "", "", "", "")
+	assert.NoError(t, err)
+	request, err := h.GetTokenRequest(netAddr)
+	assert.NoError(t, err)
+	assert.True(t, request.PincodeCredential.matches("654321"))
+
+	// Details checked against the private record still have to match
+	h.SetTransient(map[string]string{"password_hash": "pass123", "pincode": "654321", "name": "Alice", "country": "UK"})
+	err = h.Contract.RequestTokenRequest(h.Ctx, "", netAddr, "", "", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "details do not match")
+
+	// Same secret given twice is refused rather than silently picking one
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "both as argument and in the transient map")
+
+	// Secrets stay usable as arguments while the legacy switch is on
+	h.SetTransient(nil)
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "", "pass123")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token not assigned")
+
+	_, err = h.Contract.GetTokenAccess(h.Ctx, netAddr, "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be provided in the transient map")
+}
+
+func TestLegacyCredentialArgsSwitch(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+
+	netAddr, err := h.CreateParticipant("Alice", "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+
+	assert.NoError(t, h.Contract.SetLegacyCredentialArgs(h.Ctx, false))
+	err = h.Contract.RequestTokenRequest(h.Ctx, "Alice", netAddr, "pass123", "USA", "123456", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "argument form is disabled")
+
+	h.SetTransient(map[string]string{"password_hash": "pass123", "pincode": "123456", "name": "Alice"})
+	err = h.Contract.RequestTokenRequest(h.Ctx, "", netAddr, "", "USA", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "country must be provided in the transient map")
+
+	h.SetTransient(map[string]string{"password_hash": "pass123", "pincode": "123456", "name": "Alice", "country": "USA"})
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "", netAddr, "", "", "", ""))
+
+	h.SetTransient(map[string]string{"password_hash": "pass123", "new_password_hash": "newpass"})
+	assert.NoError(t, h.Contract.ChangePassword(h.Ctx, netAddr, "", ""))
+
+	// Only admins may flip the switch
+	h.SetIdentity("someone", "Org1MSP", "token_owner")
+	err = h.Contract.SetLegacyCredentialArgs(h.Ctx, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	tokenRequestWaitlisted = "WAITLISTED"
+
+	// waitlistObjectType indexes approved token requests waiting for a token, keyed by
+	// approval timestamp then address so the index iterates in FIFO order
+	waitlistObjectType = "waitlist"
+	// txTimeLayout is fixed width so timestamps sort as strings
+	txTimeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// WaitlistEntry is a participant waiting for a token, at Position from 1
+type WaitlistEntry struct {
+	Position       int    `json:"position"`
+	NetworkAddress string `json:"network_address"`
+	RequestID      string `json:"request_id"`
+	WaitlistedAt   string `json:"waitlisted_at"`
+}
+
+// txNow is the transaction timestamp, the same on every endorsing peer
+func txNow(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC(), nil
+}
+
+// txTime is txNow in txTimeLayout
+func txTime(ctx contractapi.TransactionContextInterface) (string, error) {
+	now, err := txNow(ctx)
+	if err != nil {
+		return "", err
+	}
+	return now.Format(txTimeLayout), nil
+}
+
+func waitlistKey(ctx contractapi.TransactionContextInterface, waitlistedAt, networkAddress string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(waitlistObjectType, []string{waitlistedAt, networkAddress})
+}
+
+// addToWaitlist approves r without a token, queueing it by the transaction timestamp
+func (s *SmartContract) addToWaitlist(ctx contractapi.TransactionContextInterface, r *TokenRequest) error {
+	waitlistedAt, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	r.Status = tokenRequestWaitlisted
+	r.WaitlistedAt = waitlistedAt
+	key, err := waitlistKey(ctx, r.WaitlistedAt, r.NetworkAddr)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, []byte(r.NetworkAddr)); err != nil {
+		return err
+	}
+	return s.putTokenRequest(ctx, r)
+}
+
+// assignToken gives tokenID to the participant of the approved request r
+func (s *SmartContract) assignToken(ctx contractapi.TransactionContextInterface, r *TokenRequest, p *Participant, tokenID string) error {
+	r.Status = "APPROVED"
+	r.TokenID = tokenID
+	r.WaitlistedAt = ""
+	// An approved request is final, so it moves straight to the participant's history
+	if err := ctx.GetStub().DelState("tokenrequest_" + r.NetworkAddr); err != nil {
+		return err
+	}
+	if err := s.fileTokenRequest(ctx, r); err != nil {
+		return err
+	}
+
+	p.addOwnedToken(tokenID)
+	if err := s.putParticipant(ctx, p, false); err != nil {
+		return err
+	}
+
+	t, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	t.Owner = p.NetworkAddress
+	t.Available = false
+	if err := s.removeTokenFromPool(ctx, tokenID); err != nil {
+		return err
+	}
+	return s.putToken(ctx, t)
+}
+
+// assignFromWaitlist gives tokenID, which must be available, to the longest-waiting participant
+// that can hold it. Entries whose request or participant is gone are dropped; frozen or
+// unverified participants keep their place until they can be served.
+func (s *SmartContract) assignFromWaitlist(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(waitlistObjectType, nil)
+	if err != nil {
+		return err
+	}
+	var entries []keyValue
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		entries = append(entries, keyValue{kv.Key, kv.Value})
+	}
+	iter.Close()
+
+	for _, kv := range entries {
+		r, p, err := s.waitlistedRequest(ctx, string(kv.Value))
+		if err != nil {
+			return err
+		}
+		if r == nil || p == nil {
+			if err := ctx.GetStub().DelState(kv.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		if checkNotFrozen(p) != nil || checkVerified(p) != nil {
+			continue
+		}
+		if err := ctx.GetStub().DelState(kv.Key); err != nil {
+			return err
+		}
+		return s.assignToken(ctx, r, p, tokenID)
+	}
+	return nil
+}
+
+// waitlistedRequest loads the waitlisted request and participant of networkAddress, nil if
+// either no longer exists or the request has left the waitlist
+func (s *SmartContract) waitlistedRequest(ctx contractapi.TransactionContextInterface, networkAddress string) (*TokenRequest, *Participant, error) {
+	rb, err := ctx.GetStub().GetState("tokenrequest_" + networkAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r TokenRequest
+	if rb == nil || json.Unmarshal(rb, &r) != nil || r.Status != tokenRequestWaitlisted {
+		return nil, nil, nil
+	}
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return &r, nil, nil
+	}
+	return &r, p, nil
+}
+
+// GetWaitlist lists the participants waiting for a token in assignment order (admin)
+func (s *SmartContract) GetWaitlist(ctx contractapi.TransactionContextInterface) ([]WaitlistEntry, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(waitlistObjectType, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	list := []WaitlistEntry{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 2 {
+			continue
+		}
+		list = append(list, WaitlistEntry{
+			Position:       len(list) + 1,
+			NetworkAddress: parts[1],
+			RequestID:      "tokenrequest_" + parts[1],
+			WaitlistedAt:   parts[0],
+		})
+	}
+	return list, nil
+}
+
+// moveWaitlistEntry keeps a waitlisted participant's place when its address changes
+func (s *SmartContract) moveWaitlistEntry(ctx contractapi.TransactionContextInterface, waitlistedAt, from, to string) error {
+	oldKey, err := waitlistKey(ctx, waitlistedAt, from)
+	if err != nil {
+		return err
+	}
+	if b, err := ctx.GetStub().GetState(oldKey); err != nil || b == nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(oldKey); err != nil {
+		return err
+	}
+	newKey, err := waitlistKey(ctx, waitlistedAt, to)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(newKey, []byte(to))
+}
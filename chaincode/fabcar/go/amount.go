@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Amount is a quantity of coins in integer minor units of its token (10^decimals minor units
+// make one coin). It is kept and serialized as a decimal string so no float rounding or
+// integer width ever applies; values are bounded by maxAmount.
+type Amount string
+
+const zeroAmount Amount = "0"
+
+var (
+	// maxAmount is the largest representable amount, 2^256-1 minor units
+	maxAmount = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	minorUnitsPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)$`)
+	decimalPattern    = regexp.MustCompile(`^([0-9]+)(\.([0-9]+))?$`)
+)
+
+// newAmount validates minor units held in b
+func newAmount(b *big.Int) (Amount, error) {
+	if b.Sign() < 0 {
+		return "", fmt.Errorf("amount must not be negative")
+	}
+	if b.Cmp(maxAmount) > 0 {
+		return "", fmt.Errorf("amount overflows")
+	}
+	return Amount(b.String()), nil
+}
+
+// parseMinorUnits reads an amount given as integer minor units
+func parseMinorUnits(s string) (Amount, error) {
+	if !minorUnitsPattern.MatchString(s) {
+		return "", fmt.Errorf("invalid amount %q: expected integer minor units", s)
+	}
+	b, _ := new(big.Int).SetString(s, 10)
+	return newAmount(b)
+}
+
+// parseAmount reads a coin amount such as "12.50" for a token with the given decimals.
+// Signs, exponents, NaN and more fraction digits than the token has are refused.
+func parseAmount(s string, decimals int) (Amount, error) {
+	m := decimalPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", fmt.Errorf("invalid amount %q", s)
+	}
+	frac := m[3]
+	if len(frac) > decimals {
+		return "", fmt.Errorf("invalid amount %q: token has %d decimals", s, decimals)
+	}
+	b, _ := new(big.Int).SetString(m[1]+frac+strings.Repeat("0", decimals-len(frac)), 10)
+	return newAmount(b)
+}
+
+// parsePositiveAmount is parseAmount for amounts that must move at least one minor unit
+func parsePositiveAmount(s string, decimals int) (Amount, error) {
+	a, err := parseAmount(s, decimals)
+	if err != nil {
+		return "", err
+	}
+	if a.isZero() {
+		return "", fmt.Errorf("amount must be positive")
+	}
+	return a, nil
+}
+
+// big returns the minor units; the empty amount of a missing field is zero
+func (a Amount) big() *big.Int {
+	b, ok := new(big.Int).SetString(string(a), 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return b
+}
+
+func (a Amount) isZero() bool {
+	return a.big().Sign() == 0
+}
+
+func (a Amount) cmp(b Amount) int {
+	return a.big().Cmp(b.big())
+}
+
+func (a Amount) add(b Amount) (Amount, error) {
+	return newAmount(new(big.Int).Add(a.big(), b.big()))
+}
+
+// sub fails rather than going negative; callers compare first to report what was short
+func (a Amount) sub(b Amount) (Amount, error) {
+	return newAmount(new(big.Int).Sub(a.big(), b.big()))
+}
+
+// format renders the amount in coins, e.g. "12.50" for 1250 minor units and 2 decimals
+func (a Amount) format(decimals int) string {
+	s := a.big().String()
+	if decimals <= 0 {
+		return s
+	}
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	return s[:len(s)-decimals] + "." + s[len(s)-decimals:]
+}
+
+// MarshalJSON writes the string form; an unset amount is zero
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if a == "" {
+		a = zeroAmount
+	}
+	return json.Marshal(string(a))
+}
+
+// UnmarshalJSON accepts the string form only. Numbers stored before amounts were strings count
+// whole coins, not minor units, and must be converted by MigrateAmounts first.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if json.Unmarshal(data, &n) == nil {
+			return fmt.Errorf("amount %s is a legacy number; run MigrateAmounts to convert legacy amounts", n)
+		}
+		return err
+	}
+	if s == "" {
+		*a = zeroAmount
+		return nil
+	}
+	v, err := parseMinorUnits(s)
+	if err != nil {
+		return fmt.Errorf("%v; run MigrateAmounts to convert legacy amounts", err)
+	}
+	*a = v
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// amountFields lists the amount fields of each record type, by key prefix
+var amountFields = map[string][]string{
+	tokenIDPrefix:       {"minted", "total_minted", "max_supply", "max_mint_per_request", "burned"},
+	"mintrequest_":      {"amount"},
+	"custmintreq_":      {"amount"},
+	"customer_":         {"balance", "redeeming"},
+	"transfer_":         {"amount"},
+	redemptionKeyPrefix: {"amount"},
+	settlementKeyPrefix: {"amount"},
+	revocationKeyPrefix: {"minted_forfeited"},
+}
+
+// amountsMigratedKey marks a ledger holding no legacy amounts, see requireAmountsMigrated
+const amountsMigratedKey = "config_amounts_migrated"
+
+// amountPassKey holds the amountPass of the latest MigrateAmounts run
+const amountPassKey = "config_amounts_pass"
+
+// AmountMigration reports what one batch of MigrateAmounts rewrote
+type AmountMigration struct {
+	Migrated int      `json:"migrated"`
+	Skipped  []string `json:"skipped"`  // keys holding negative or oversized legacy values, left for manual repair
+	Bookmark string   `json:"bookmark"` // startKey of the next batch, empty once the ledger is done
+}
+
+// amountPass tracks a MigrateAmounts pass over the ledger, so the ledger only opens once every
+// batch from the first key to the last has run without skipping records
+type amountPass struct {
+	Next    string `json:"next"`
+	Skipped bool   `json:"skipped"`
+}
+
+// legacyMinorUnits converts a legacy whole-coin JSON number to minor units. Fractions below
+// one minor unit are truncated, as the old int conversions did.
+func legacyMinorUnits(value string, decimals int) (Amount, bool) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return "", false
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+	a, err := newAmount(new(big.Int).Quo(r.Num(), r.Denom()))
+	return a, err == nil
+}
+
+// MigrateAmounts rewrites amounts stored as JSON numbers into string minor units of their
+// token (admin), pageSize records from startKey at a time; run it again from the returned bookmark
+// until that is empty. Safe to run repeatedly. The ledger opens for other transactions once a
+// pass from the first key to the last leaves nothing skipped.
+func (s *SmartContract) MigrateAmounts(ctx contractapi.TransactionContextInterface, startKey string, pageSize int) (*AmountMigration, error) {
+	if err := s.verifyRegisteredAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var pass amountPass
+	if startKey != "" {
+		b, err := ctx.GetStub().GetState(amountPassKey)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil || json.Unmarshal(b, &pass) != nil || pass.Next != startKey {
+			return nil, fmt.Errorf("start key %s does not continue the current pass: resume from its bookmark or start again from the first key", startKey)
+		}
+	}
+	records, bookmark, err := migrationPage(ctx, startKey, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals := make(map[string]int)
+	res := &AmountMigration{Skipped: []string{}, Bookmark: bookmark}
+	for _, kv := range records {
+		fields := recordAmountFields(kv.Key)
+		if fields == nil {
+			continue
+		}
+		var raw map[string]json.RawMessage
+		if json.Unmarshal(kv.Value, &raw) != nil {
+			continue
+		}
+		tokenID := kv.Key
+		if !strings.HasPrefix(kv.Key, tokenIDPrefix) {
+			json.Unmarshal(raw["token_id"], &tokenID)
+		}
+		d, ok := decimals[tokenID]
+		if !ok {
+			if d, err = legacyTokenDecimals(ctx, tokenID); err != nil {
+				return nil, err
+			}
+			decimals[tokenID] = d
+		}
+
+		changed, skipped := false, false
+		for _, f := range fields {
+			v, ok := raw[f]
+			if !ok || len(v) == 0 || v[0] == '"' || string(v) == "null" {
+				continue
+			}
+			a, ok := legacyMinorUnits(string(v), d)
+			if !ok {
+				skipped = true
+				break
+			}
+			quoted, _ := json.Marshal(a)
+			raw[f] = quoted
+			changed = true
+		}
+		if skipped {
+			res.Skipped = append(res.Skipped, kv.Key)
+			continue
+		}
+		if changed {
+			b, err := json.Marshal(raw)
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.GetStub().PutState(kv.Key, b); err != nil {
+				return nil, err
+			}
+			res.Migrated++
+		}
+	}
+
+	pass.Next = bookmark
+	pass.Skipped = pass.Skipped || len(res.Skipped) > 0
+	if bookmark == "" && !pass.Skipped {
+		if err := ctx.GetStub().PutState(amountsMigratedKey, []byte("true")); err != nil {
+			return nil, err
+		}
+	}
+	pb, err := json.Marshal(pass)
+	if err != nil {
+		return nil, err
+	}
+	return res, ctx.GetStub().PutState(amountPassKey, pb)
+}
+
+// legacyTokenDecimals reads the decimals of tokenID without decoding its amounts, which may
+// still be legacy numbers
+func legacyTokenDecimals(ctx contractapi.TransactionContextInterface, tokenID string) (int, error) {
+	b, err := ctx.GetStub().GetState(tokenID)
+	if err != nil {
+		return 0, err
+	}
+	var t struct {
+		Metadata *TokenMetadata `json:"metadata"`
+	}
+	if b == nil || !strings.HasPrefix(tokenID, tokenIDPrefix) || json.Unmarshal(b, &t) != nil || t.Metadata == nil {
+		return 0, nil
+	}
+	return t.Metadata.Decimals, nil
+}
+
+func recordAmountFields(key string) []string {
+	for prefix, fields := range amountFields {
+		if strings.HasPrefix(key, prefix) {
+			return fields
+		}
+	}
+	return nil
+}
+
+// requireAmountsMigrated refuses to work on a ledger that may still hold legacy amounts, which
+// would otherwise be skipped or misread
+func (s *SmartContract) requireAmountsMigrated(ctx contractapi.TransactionContextInterface) error {
+	b, err := ctx.GetStub().GetState(amountsMigratedKey)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return fmt.Errorf("stored amounts are not migrated yet: an admin must run MigrateAmounts")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateAmountsConvertsTransfers(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	h.Stub.State["token_1"] = []byte(`{"token_id":"token_1","owner":"addr1","minted":7,"metadata":{"name":"One","symbol":"ONE","decimals":2}}`)
+	h.Stub.State["transfer_1"] = []byte(`{"transfer_id":"transfer_1","sender_transfer_id":"sender1","token_id":"token_1","amount":3}`)
+	delete(h.Stub.State, amountsMigratedKey)
+
+	_, err := h.Contract.GetTokenTransferHistory(h.Ctx, "token_1")
+	assert.Error(t, err)
+
+	res, err := h.Contract.MigrateAmounts(h.Ctx, "", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Migrated)
+	assert.Empty(t, res.Skipped)
+
+	var transfer TransferRequest
+	assert.NoError(t, json.Unmarshal(h.Stub.State["transfer_1"], &transfer))
+	assert.Equal(t, Amount("300"), transfer.Amount)
+	token, err := h.GetToken("token_1")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("700"), token.Minted)
+}
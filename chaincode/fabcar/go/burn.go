@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// BurnCoins lets the token owner destroy coins it holds; burned coins stay counted in
+// TotalMinted, so burning does not free room under the supply cap
+func (s *SmartContract) BurnCoins(ctx contractapi.TransactionContextInterface, tokenID, amount string) error {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return err
+	}
+	owner, err := s.callerParticipant(ctx, "")
+	if err != nil {
+		return err
+	}
+	if err := checkNotFrozen(owner); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if token.Owner != owner.NetworkAddress {
+		return fmt.Errorf("caller is not token owner")
+	}
+	d := token.decimals()
+	burn, err := parsePositiveAmount(amount, d)
+	if err != nil {
+		return err
+	}
+	if burn.cmp(token.Minted) > 0 {
+		return fmt.Errorf("insufficient minted coin balance on token: available %s, requested %s", token.Minted.format(d), burn.format(d))
+	}
+
+	token.TotalMinted = token.totalMinted()
+	if token.Minted, err = token.Minted.sub(burn); err != nil {
+		return err
+	}
+	if token.Burned, err = token.Burned.add(burn); err != nil {
+		return err
+	}
+	return s.putToken(ctx, token)
+}
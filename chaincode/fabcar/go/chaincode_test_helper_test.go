@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TestHelper provides easy methods to test chaincode functions
+type TestHelper struct {
+	Stub     *mockStub
+	Ctx      *mockContext
+	Contract *SmartContract
+	txCount  int
+}
+
+// NewTestHelper creates a new test helper with initialized state
+func NewTestHelper() *TestHelper {
+	// Tests start from a ledger without legacy amounts, as InitLedger leaves a fresh one
+	stub := &mockStub{State: map[string][]byte{amountsMigratedKey: []byte("true")}}
+	ctx := &mockContext{
+		stub:           stub,
+		clientIdentity: &mockClientIdentity{},
+	}
+	return &TestHelper{
+		Stub:     stub,
+		Ctx:      ctx,
+		Contract: new(SmartContract),
+	}
+}
+
+// CreateParticipant adds a test participant to the ledger
+func (h *TestHelper) CreateParticipant(name, password, country string) (string, error) {
+	return h.Contract.SubmitRegistration(h.Ctx, name, password, country)
+}
+
+// GetParticipant retrieves a participant from the ledger joined with its private details
+func (h *TestHelper) GetParticipant(networkAddress string) (*Participant, error) {
+	p, err := h.Contract.getParticipant(h.Ctx, networkAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Contract.loadParticipantPII(h.Ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetToken retrieves a token from the ledger
+func (h *TestHelper) GetToken(tokenID string) (*Token, error) {
+	data, err := h.Stub.GetState(tokenID)
+	if err != nil || data == nil {
+		return nil, fmt.Errorf("token not found")
+	}
+	var t Token
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// InitLedgerWithTokens initializes the ledger with tokens
+func (h *TestHelper) InitLedgerWithTokens() error {
+	return h.Contract.InitLedger(h.Ctx)
+}
+
+// GetTokenRequest gets a token request from the ledger
+func (h *TestHelper) GetTokenRequest(networkAddress string) (*TokenRequest, error) {
+	reqID := "tokenrequest_" + networkAddress
+	data, err := h.Stub.GetState(reqID)
+	if err != nil || data == nil {
+		return nil, fmt.Errorf("token request not found")
+	}
+	var tr TokenRequest
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return nil, err
+	}
+	return &tr, nil
+}
+
+// GetFiledTokenRequest gets a token request from the participant's history
+func (h *TestHelper) GetFiledTokenRequest(networkAddress string, sequence int) (*TokenRequest, error) {
+	data, err := h.Stub.GetState(tokenRequestHistoryKey(networkAddress, sequence))
+	if err != nil || data == nil {
+		return nil, fmt.Errorf("token request not found")
+	}
+	var tr TokenRequest
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return nil, err
+	}
+	return &tr, nil
+}
+
+// GetMintRequest gets a mint request from the ledger
+func (h *TestHelper) GetMintRequest(requestID string) (*MintRequest, error) {
+	data, err := h.Stub.GetState(requestID)
+	if err != nil || data == nil {
+		return nil, fmt.Errorf("mint request not found")
+	}
+	var mr MintRequest
+	if err := json.Unmarshal(data, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// GetCustomer retrieves a customer of a token from the ledger joined with its private details
+func (h *TestHelper) GetCustomer(networkAddress, tokenID string) (*Customer, error) {
+	key := "customer_" + networkAddress + "_" + tokenID
+	cust, err := h.Contract.getCustomer(h.Ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Contract.loadCustomerPII(h.Ctx, key, cust); err != nil {
+		return nil, err
+	}
+	return cust, nil
+}
+
+// NewTx gives the following calls a fresh transaction ID, as separate submissions would have
+func (h *TestHelper) NewTx() {
+	h.txCount++
+	h.Stub.TxID = fmt.Sprintf("tx%d", h.txCount)
+}
+
+// SetAsAdmin makes the test context use admin identity
+func (h *TestHelper) SetAsAdmin() {
+	h.Ctx.clientIdentity = &mockClientIdentity{} // default returns Org1MSP
+}
+
+// SetAsNonAdmin makes the test context use non-admin identity
+func (h *TestHelper) SetAsNonAdmin() {
+	h.Ctx.clientIdentity = &mockNonAdminIdentity{} // returns Org2MSP
+}
+
+// SetIdentity makes the test context use an arbitrary client identity;
+// an empty role leaves the role attribute unset
+func (h *TestHelper) SetIdentity(id, mspID, role string) {
+	attrs := map[string]string{}
+	if role != "" {
+		attrs["role"] = role
+	}
+	h.SetIdentityWithAttributes(id, mspID, attrs)
+}
+
+// SetIdentityWithAttributes makes the test context use an identity with the given certificate attributes
+func (h *TestHelper) SetIdentityWithAttributes(id, mspID string, attrs map[string]string) {
+	h.Ctx.clientIdentity = &mockCustomIdentity{id: id, mspID: mspID, attrs: attrs}
+}
+
+// SetTransient sets the transient map passed with the next calls; nil clears it
+func (h *TestHelper) SetTransient(values map[string]string) {
+	h.Stub.Transient = nil
+	if values == nil {
+		return
+	}
+	h.Stub.Transient = make(map[string][]byte)
+	for k, v := range values {
+		h.Stub.Transient[k] = []byte(v)
+	}
+}
+
+// VerifyParticipant moves a registered participant to VERIFIED as the admin identity,
+// keeping the current identity for the following calls
+func (h *TestHelper) VerifyParticipant(networkAddress string) error {
+	current := h.Ctx.clientIdentity
+	defer func() { h.Ctx.clientIdentity = current }()
+	h.SetAsAdmin()
+	return h.Contract.VerifyParticipant(h.Ctx, networkAddress)
+}
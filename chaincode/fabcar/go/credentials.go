@@ -0,0 +1,320 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Password KDF settings for new credentials; existing credentials keep the iterations they were created with
+const (
+	passwordScheme     = "pbkdf2-sha256"
+	passwordIterations = 100000
+	passwordKeyLength  = 32
+)
+
+// PasswordCredential is a salted, slow-hashed account secret. Records created before
+// credentials existed only carry the legacy password_hash, compared verbatim until migrated.
+type PasswordCredential struct {
+	Scheme        string `json:"scheme"`
+	Salt          string `json:"salt"`
+	Iterations    int    `json:"iterations"`
+	Hash          string `json:"hash"`
+	ResetRequired bool   `json:"reset_required"`
+}
+
+// newPasswordCredential derives a credential for secret. The salt comes from the transaction ID
+// and the account key so every endorser computes the same value.
+func newPasswordCredential(ctx contractapi.TransactionContextInterface, secret, accountKey string) (*PasswordCredential, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("password must not be empty")
+	}
+	salt := sha256.Sum256([]byte(ctx.GetStub().GetTxID() + "\x00" + accountKey))
+	c := &PasswordCredential{
+		Scheme:     passwordScheme,
+		Salt:       hex.EncodeToString(salt[:]),
+		Iterations: passwordIterations,
+	}
+	c.Hash = c.derive(secret)
+	return c, nil
+}
+
+func (c *PasswordCredential) derive(secret string) string {
+	salt, _ := hex.DecodeString(c.Salt)
+	return hex.EncodeToString(pbkdf2SHA256([]byte(secret), salt, c.Iterations, passwordKeyLength))
+}
+
+// matches reports whether secret derives to the stored hash
+func (c *PasswordCredential) matches(secret string) bool {
+	if c.Scheme != passwordScheme {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(c.derive(secret)), []byte(c.Hash)) == 1
+}
+
+// secretMatches checks secret against cred, or against legacyHash for unmigrated records
+func secretMatches(legacyHash string, cred *PasswordCredential, secret string) bool {
+	if cred == nil {
+		return legacyHash != "" && subtle.ConstantTimeCompare([]byte(legacyHash), []byte(secret)) == 1
+	}
+	return cred.matches(secret)
+}
+
+// verifySecret authenticates an account secret and refuses credentials awaiting a password change
+func verifySecret(legacyHash string, cred *PasswordCredential, secret string) error {
+	if !secretMatches(legacyHash, cred, secret) {
+		return fmt.Errorf("password mismatch")
+	}
+	if cred != nil && cred.ResetRequired {
+		return fmt.Errorf("password was reset: change password before continuing")
+	}
+	return nil
+}
+
+// clearSecrets drops the password material from a participant about to be returned to a caller
+func (p *Participant) clearSecrets() {
+	p.PasswordHash, p.Credential = "", nil
+}
+
+// clearSecrets drops the password material from a customer request about to be returned to a caller
+func (r *RegisterCustomerRequest) clearSecrets() {
+	r.PasswordHash, r.Credential = "", nil
+}
+
+// pbkdf2SHA256 implements PBKDF2 (RFC 8018, section 5.2) with HMAC-SHA256 as PRF
+func pbkdf2SHA256(secret, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, secret)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var counter [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		counter[0] = byte(block >> 24)
+		counter[1] = byte(block >> 16)
+		counter[2] = byte(block >> 8)
+		counter[3] = byte(block)
+		prf.Write(counter[:])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
+
+// ChangePassword replaces the caller's participant password; networkAddress is optional
+func (s *SmartContract) ChangePassword(ctx contractapi.TransactionContextInterface, networkAddress, currentPassword, newPassword string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	currentPassword, err := s.secretInput(ctx, transientPasswordKey, currentPassword)
+	if err != nil {
+		return err
+	}
+	newPassword, err = s.secretInput(ctx, transientNewPasswordKey, newPassword)
+	if err != nil {
+		return err
+	}
+
+	p, err := s.callerParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return err
+	}
+	if !secretMatches(p.PasswordHash, p.Credential, currentPassword) {
+		return fmt.Errorf("password mismatch")
+	}
+
+	cred, err := newPasswordCredential(ctx, newPassword, p.NetworkAddress)
+	if err != nil {
+		return err
+	}
+	p.Credential = cred
+	p.PasswordHash = ""
+	return s.putParticipant(ctx, p, true)
+}
+
+// ResetPassword lets an admin set a temporary participant password that must be changed before next use
+func (s *SmartContract) ResetPassword(ctx contractapi.TransactionContextInterface, networkAddress, temporaryPassword string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	temporaryPassword, err := s.secretInput(ctx, transientNewPasswordKey, temporaryPassword)
+	if err != nil {
+		return err
+	}
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return err
+	}
+
+	cred, err := newPasswordCredential(ctx, temporaryPassword, networkAddress)
+	if err != nil {
+		return err
+	}
+	cred.ResetRequired = true
+	p.Credential = cred
+	p.PasswordHash = ""
+	return s.putParticipant(ctx, p, true)
+}
+
+// ChangeCustomerPassword replaces a customer's password for the given token
+func (s *SmartContract) ChangeCustomerPassword(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, currentPassword, newPassword string) error {
+	if err := s.requirePermission(ctx, permTransact); err != nil {
+		return err
+	}
+	currentPassword, err := s.secretInput(ctx, transientPasswordKey, currentPassword)
+	if err != nil {
+		return err
+	}
+	newPassword, err = s.secretInput(ctx, transientNewPasswordKey, newPassword)
+	if err != nil {
+		return err
+	}
+
+	customerKey := "customer_" + networkAddress + "_" + tokenID
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
+		return err
+	}
+	if err := s.loadCustomerPII(ctx, customerKey, cust); err != nil {
+		return err
+	}
+	if !secretMatches(cust.PasswordHash, cust.Credential, currentPassword) {
+		return fmt.Errorf("password mismatch")
+	}
+
+	cred, err := newPasswordCredential(ctx, newPassword, customerKey)
+	if err != nil {
+		return err
+	}
+	cust.Credential = cred
+	cust.PasswordHash = ""
+	return s.putCustomer(ctx, customerKey, cust, true)
+}
+
+// ResetCustomerPassword lets an admin set a temporary customer password that must be changed before next use
+func (s *SmartContract) ResetCustomerPassword(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, temporaryPassword string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	temporaryPassword, err := s.secretInput(ctx, transientNewPasswordKey, temporaryPassword)
+	if err != nil {
+		return err
+	}
+
+	customerKey := "customer_" + networkAddress + "_" + tokenID
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
+		return err
+	}
+	if err := s.loadCustomerPII(ctx, customerKey, cust); err != nil {
+		return err
+	}
+
+	cred, err := newPasswordCredential(ctx, temporaryPassword, customerKey)
+	if err != nil {
+		return err
+	}
+	cred.ResetRequired = true
+	cust.Credential = cred
+	cust.PasswordHash = ""
+	return s.putCustomer(ctx, customerKey, cust, true)
+}
+
+// MigrateCredentials converts legacy password_hash values into salted credentials, pageSize
+// records from startKey at a time; run it again from the returned bookmark until that is empty.
+// The stored value is what clients keep sending, so it becomes the secret fed to the KDF.
+func (s *SmartContract) MigrateCredentials(ctx contractapi.TransactionContextInterface, startKey string, pageSize int) (*MigrationPage, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	records, bookmark, err := migrationPage(ctx, startKey, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MigrationPage{Bookmark: bookmark}
+	for _, kv := range records {
+		switch {
+		case strings.HasPrefix(kv.Key, "customer_"):
+			var cust Customer
+			if json.Unmarshal(kv.Value, &cust) != nil {
+				continue
+			}
+			if err := s.loadCustomerPII(ctx, kv.Key, &cust); err != nil {
+				return nil, err
+			}
+			if cust.Credential != nil || cust.PasswordHash == "" {
+				continue
+			}
+			if cust.Credential, err = newPasswordCredential(ctx, cust.PasswordHash, kv.Key); err != nil {
+				return nil, err
+			}
+			cust.PasswordHash = ""
+			err = s.putCustomer(ctx, kv.Key, &cust, true)
+		case strings.HasPrefix(kv.Key, "custreq_"):
+			var req RegisterCustomerRequest
+			if json.Unmarshal(kv.Value, &req) != nil {
+				continue
+			}
+			if err := s.loadCustomerRequestPII(ctx, &req); err != nil {
+				return nil, err
+			}
+			if req.Credential != nil || req.PasswordHash == "" {
+				continue
+			}
+			if req.Credential, err = newPasswordCredential(ctx, req.PasswordHash, kv.Key); err != nil {
+				return nil, err
+			}
+			req.PasswordHash = ""
+			err = s.putCustomerRequest(ctx, &req, true)
+		default:
+			var p Participant
+			if json.Unmarshal(kv.Value, &p) != nil || p.NetworkAddress != kv.Key {
+				continue
+			}
+			if err := s.loadParticipantPII(ctx, &p); err != nil {
+				return nil, err
+			}
+			if p.Credential != nil || p.PasswordHash == "" {
+				continue
+			}
+			if p.Credential, err = newPasswordCredential(ctx, p.PasswordHash, kv.Key); err != nil {
+				return nil, err
+			}
+			p.PasswordHash = ""
+			err = s.putParticipant(ctx, &p, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Migrated++
+	}
+	return res, nil
+}
@@ -1,11 +1,8 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -17,97 +14,177 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// Participant is the joined view of a participant; Name, Country and the password fields
+// live in the PII collection and are empty on the public record
 type Participant struct {
-	Name           string   `json:"name"`
-	NetworkAddress string   `json:"network_address"`
-	ClientID       string   `json:"client_id"`
-	Approved       bool     `json:"approved"`
-	PasswordHash   string   `json:"password_hash"`
-	Country        string   `json:"country"`
-	TokenID        string   `json:"token_id"`
-	TransferIDs    []string `json:"transfer_ids"`
+	Name            string              `json:"name,omitempty"`
+	NetworkAddress  string              `json:"network_address"`
+	ClientID        string              `json:"client_id,omitempty"` // legacy single identity, see ClientIDs
+	ClientIDs       []string            `json:"client_ids"`          // client identities allowed to act for the participant
+	Approved        bool                `json:"approved"`            // a token request was approved, onboarding state is Status
+	Status          string              `json:"status"`              // REGISTERED, UNDER_REVIEW, VERIFIED, REJECTED, SUSPENDED, CLOSED
+	StatusReason    string              `json:"status_reason,omitempty"`
+	StatusUpdatedBy string              `json:"status_updated_by,omitempty"`
+	PasswordHash    string              `json:"password_hash,omitempty"` // legacy verbatim secret, cleared once Credential is set
+	Credential      *PasswordCredential `json:"credential,omitempty"`
+	Country         string              `json:"country,omitempty"`
+	TokenID         string              `json:"token_id,omitempty"` // legacy single token, see TokenIDs
+	TokenIDs        []string            `json:"token_ids"`          // tokens the participant owns
+	TransferIDs     []string            `json:"transfer_ids"`
+	Frozen          bool                `json:"frozen"`
+	FreezeReason    string              `json:"freeze_reason,omitempty"` // reason code, see FreezeParticipant
+	FrozenBy        string              `json:"frozen_by,omitempty"`
+	PrivateDataHash string              `json:"private_data_hash"` // sha256 of the PII collection record
 }
 
 type Token struct {
-	TokenID     string   `json:"token_id"`
-	Owner       string   `json:"owner"`
-	Available   bool     `json:"available"`
-	Minted      int      `json:"minted"`
-	TransferIDs []string `json:"transfer_ids"`
+	TokenID               string         `json:"token_id"`
+	Owner                 string         `json:"owner"`
+	Available             bool           `json:"available"`
+	Minted                Amount         `json:"minted"`                            // coins held by the owner, not yet issued to customers
+	TotalMinted           Amount         `json:"total_minted"`                      // supply ever minted, see totalMinted
+	MaxSupply             Amount         `json:"max_supply"`                        // cap on TotalMinted, 0 for none
+	MaxMintPerRequest     Amount         `json:"max_mint_per_request"`              // 0 for none
+	MintApprovalThreshold Amount         `json:"mint_approval_threshold,omitempty"` // mints above it need MintApprovalsRequired admins
+	MintApprovalsRequired int            `json:"mint_approvals_required,omitempty"`
+	Burned                Amount         `json:"burned"`
+	TransferIDs           []string       `json:"transfer_ids"`
+	Metadata              *TokenMetadata `json:"metadata,omitempty"`
+	PendingMetadata       *TokenMetadata `json:"pending_metadata,omitempty"` // new symbol awaiting admin review
+	MetadataRejection     string         `json:"metadata_rejection,omitempty"`
+	Paused                bool           `json:"paused"` // customer activity halted, see PauseToken
+	PauseReason           string         `json:"pause_reason,omitempty"`
+	PausedBy              string         `json:"paused_by,omitempty"`
+	PausedByAdmin         bool           `json:"paused_by_admin,omitempty"`
 }
 
 type TokenRequest struct {
-	RequestID   string `json:"request_id"`
-	NetworkAddr string `json:"network_addr"`
-	Status      string `json:"status"` // PENDING, APPROVED
-	TokenID     string `json:"token_id"`
+	RequestID    string `json:"request_id"`
+	NetworkAddr  string `json:"network_addr"`
+	Status       string `json:"status"` // PENDING, WAITLISTED, APPROVED, REJECTED, CANCELLED, REVOKED, TRANSFERRED
+	TokenID      string `json:"token_id"`
+	WaitlistedAt string `json:"waitlisted_at,omitempty"` // approval time, while waiting for a token
+	Purpose      string `json:"purpose,omitempty"`       // what the token is for, required for additional tokens
+	Reason       string `json:"reason,omitempty"`        // why the request was rejected
+	Sequence     int    `json:"sequence,omitempty"`      // position in the participant's request history, from 1
 }
 
 type MintRequest struct {
-	RequestID   string `json:"request_id"`
-	TokenID     string `json:"token_id"`
-	RequestedBy string `json:"requested_by"`
-	Amount      int    `json:"amount"`
-	Approved    bool   `json:"approved"`
+	RequestID         string         `json:"request_id"`
+	TokenID           string         `json:"token_id"`
+	RequestedBy       string         `json:"requested_by"`
+	Amount            Amount         `json:"amount"`
+	Status            string         `json:"status"`                       // PENDING, APPROVED, REJECTED, WITHDRAWN, EXPIRED
+	Approved          bool           `json:"approved"`                     // kept in step with Status for existing clients
+	Cancelled         bool           `json:"cancelled,omitempty"`          // legacy: the token was revoked or transferred before approval
+	Reason            string         `json:"reason,omitempty"`             // why the request was rejected
+	RequestedAt       string         `json:"requested_at,omitempty"`       // transaction time the request was made
+	ExpiresAt         string         `json:"expires_at,omitempty"`         // a request still pending at this time has expired
+	Approvals         []MintApproval `json:"approvals,omitempty"`          // admins that approved, see GetMintApprovals
+	ApprovalsRequired int            `json:"approvals_required,omitempty"` // set when filed, see mintApprovalsRequired
 }
 
-// Customer struct to track customer info linked to a token
+// Customer struct to track customer info linked to a token; Name and the password fields
+// live in the PII collection and are empty on the public record
 type Customer struct {
-	NetworkAddress   string   `json:"network_address"`
-	Name             string   `json:"name"`
-	PasswordHash     string   `json:"password_hash"`
-	TokenID          string   `json:"token_id"`
-	Approved         bool     `json:"approved"`
-	Balance          int      `json:"balance"`
-	TransferIDs      []string `json:"transfer_ids"` // List of transfer IDs related to customer
-	TokenTransferIDs []string `json:"token_transfer_ids"`
+	NetworkAddress   string              `json:"network_address"`
+	Name             string              `json:"name,omitempty"`
+	PasswordHash     string              `json:"password_hash,omitempty"` // legacy verbatim secret, cleared once Credential is set
+	Credential       *PasswordCredential `json:"credential,omitempty"`
+	TokenID          string              `json:"token_id"`
+	Approved         bool                `json:"approved"`
+	Balance          Amount              `json:"balance"`
+	Redeeming        Amount              `json:"redeeming"`    // held for pending redemptions
+	Frozen           bool                `json:"frozen"`       // balance locked by a token revocation
+	TransferIDs      []string            `json:"transfer_ids"` // List of transfer IDs related to customer
+	TokenTransferIDs []string            `json:"token_transfer_ids"`
+	PrivateDataHash  string              `json:"private_data_hash"`
 }
 
-// RegisterCustomerRequest for pending customer registrations
+// RegisterCustomerRequest for pending customer registrations; PII is kept like on Customer
 type RegisterCustomerRequest struct {
-	RequestID      string `json:"request_id"`
-	NetworkAddress string `json:"network_address"`
-	Name           string `json:"name"`
-	PasswordHash   string `json:"password_hash"`
-	TokenID        string `json:"token_id"`
-	Approved       bool   `json:"approved"`
+	RequestID       string              `json:"request_id"`
+	NetworkAddress  string              `json:"network_address"`
+	Name            string              `json:"name,omitempty"`
+	PasswordHash    string              `json:"password_hash,omitempty"` // legacy verbatim secret, cleared once Credential is set
+	Credential      *PasswordCredential `json:"credential,omitempty"`
+	TokenID         string              `json:"token_id"`
+	Approved        bool                `json:"approved"`
+	Cancelled       bool                `json:"cancelled,omitempty"` // the token was revoked; a new request may replace it
+	PrivateDataHash string              `json:"private_data_hash"`
 }
 
 type TransferRequest struct {
-	TransferRequestID       string  `json:"transfer_request_id"`
-	TokenID                 string  `json:"token_id"`
-	Amount                  float64 `json:"amount"`
-	SenderTransferID        string  `json:"sender_transfer_id"`
-	ReceiverTransferID      string  `json:"receiver_transfer_id"`
-	SenderTokenTransferID   string  `json:"sender_token_transfer_id"`
-	ReceiverTokenTransferID string  `json:"receiver_token_transfer_id"`
-	Status                  string  `json:"status"` // PendingOwnerApproval, PendingReceiverApproval, Completed, Rejected
+	TransferRequestID       string `json:"transfer_request_id"`
+	TokenID                 string `json:"token_id"`
+	Amount                  Amount `json:"amount"`
+	SenderTransferID        string `json:"sender_transfer_id"`
+	ReceiverTransferID      string `json:"receiver_transfer_id"`
+	SenderTokenTransferID   string `json:"sender_token_transfer_id"`
+	ReceiverTokenTransferID string `json:"receiver_token_transfer_id"`
+	Status                  string `json:"status"` // PendingOwnerApproval, PendingReceiverApproval, Completed, Rejected, Cancelled
 }
 
-const maxTokens = 25
-
-// InitLedger initializes token pool
+// InitLedger bootstraps the admin registry and initializes token pool
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	for i := 1; i <= maxTokens; i++ {
-		tid := fmt.Sprintf("token_%d", i)
-		token := Token{TokenID: tid, Owner: "", Available: true, Minted: 0}
-		b, err := json.Marshal(token)
-		if err != nil {
-			return err
-		}
-		if err := ctx.GetStub().PutState(tid, b); err != nil {
-			return err
-		}
+	if err := s.checkPermission(ctx, permAdminister); err != nil {
+		return err
+	}
+	if err := s.initAdminRegistry(ctx); err != nil {
+		return err
 	}
 
-	return nil
+	// Re-invoking InitLedger never touches existing tokens
+	initialized, err := ctx.GetStub().GetState(tokenSeqKey)
+	if err != nil {
+		return err
+	}
+	if initialized != nil {
+		return nil
+	}
+	seq, err := s.ensureTokenPool(ctx)
+	if err != nil || seq > 0 {
+		return err
+	}
+	if _, err := s.createTokens(ctx, 0, initialTokens); err != nil {
+		return err
+	}
+	// A fresh ledger has no legacy amounts to migrate
+	return ctx.GetStub().PutState(amountsMigratedKey, []byte("true"))
 }
 
-// SubmitRegistration registers participant with generated network address
+// SubmitRegistration registers participant with a network address derived from the caller's certificate
 func (s *SmartContract) SubmitRegistration(ctx contractapi.TransactionContextInterface, name, passwordHash, country string) (string, error) {
-	hash := sha256.Sum256([]byte(name))
-	netAddr := hex.EncodeToString(hash[:])
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
+	}
+	if name, err = s.secretInput(ctx, transientNameKey, name); err != nil {
+		return "", err
+	}
+	if country, err = s.secretInput(ctx, transientCountryKey, country); err != nil {
+		return "", err
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", err
+	}
+	bound, err := ctx.GetStub().GetState(participantIDKey(clientID))
+	if err != nil {
+		return "", err
+	}
+	if bound != nil {
+		return "", fmt.Errorf("caller already registered as participant")
+	}
 
+	netAddr, err := deriveNetworkAddress(ctx)
+	if err != nil {
+		return "", err
+	}
 	exists, err := s.ParticipantExists(ctx, netAddr)
 	if err != nil {
 		return "", err
@@ -116,20 +193,25 @@ func (s *SmartContract) SubmitRegistration(ctx contractapi.TransactionContextInt
 		return "", fmt.Errorf("participant already exists")
 	}
 
-	clientID, err := ctx.GetClientIdentity().GetID()
+	cred, err := newPasswordCredential(ctx, passwordHash, netAddr)
 	if err != nil {
 		return "", err
 	}
 
-	p := Participant{Name: name, NetworkAddress: netAddr, ClientID: clientID, Approved: false, PasswordHash: passwordHash, Country: country, TokenID: ""}
-	b, _ := json.Marshal(p)
-	if err := ctx.GetStub().PutState(netAddr, b); err != nil {
+	p := Participant{Name: name, NetworkAddress: netAddr, ClientIDs: []string{clientID}, Approved: false, Status: statusRegistered, Credential: cred, Country: country}
+	if err := s.putParticipant(ctx, &p, true); err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(participantIDKey(clientID), []byte(netAddr)); err != nil {
 		return "", err
 	}
 	return netAddr, nil
 }
 
 func (s *SmartContract) ParticipantExists(ctx contractapi.TransactionContextInterface, networkAddress string) (bool, error) {
+	if err := s.requirePermission(ctx, permReadPublic); err != nil {
+		return false, err
+	}
 	b, err := ctx.GetStub().GetState(networkAddress)
 	if err != nil {
 		return false, err
@@ -137,35 +219,52 @@ func (s *SmartContract) ParticipantExists(ctx contractapi.TransactionContextInte
 	return b != nil, nil
 }
 
-// VerifyAdmin restricts to admin
-func (s *SmartContract) VerifyAdmin(ctx contractapi.TransactionContextInterface) error {
-	msp, err := ctx.GetClientIdentity().GetMSPID()
+// RequestTokenRequest allows participant to request token purchase; only if details match.
+// Participants that already own a token may request more, saying what each is for in purpose.
+func (s *SmartContract) RequestTokenRequest(ctx contractapi.TransactionContextInterface, name, networkAddress, passwordHash, country, purpose string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
 	if err != nil {
 		return err
 	}
-	if msp != "Org1MSP" {
-		return fmt.Errorf("access denied: admin only")
+	if name, err = s.secretInput(ctx, transientNameKey, name); err != nil {
+		return err
+	}
+	if country, err = s.secretInput(ctx, transientCountryKey, country); err != nil {
+		return err
 	}
-	return nil
-}
 
-// RequestTokenRequest allows participant to request token purchase; only if details match
-func (s *SmartContract) RequestTokenRequest(ctx contractapi.TransactionContextInterface, name, networkAddress, passwordHash, country string) error {
-	b, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || b == nil {
-		return fmt.Errorf("participant not found")
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
 	}
-	var p Participant
-	if err := json.Unmarshal(b, &p); err != nil {
+	if err := s.loadParticipantPII(ctx, p); err != nil {
 		return err
 	}
 
-	if p.Name != name || p.PasswordHash != passwordHash || p.Country != country {
+	if p.Name != name || p.Country != country || verifySecret(p.PasswordHash, p.Credential, passwordHash) != nil {
 		return fmt.Errorf("participant details do not match")
 	}
+	if err := checkVerified(p); err != nil {
+		return err
+	}
+	if err := checkNotFrozen(p); err != nil {
+		return err
+	}
+
+	if purpose == "" && len(p.ownedTokenIDs()) > 0 {
+		return fmt.Errorf("purpose is required when requesting an additional token")
+	}
+	// An open request is never replaced; a closed one moves to the participant's history
+	seq, err := s.archiveTokenRequest(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
 
 	reqID := "tokenrequest_" + networkAddress
-	req := TokenRequest{RequestID: reqID, NetworkAddr: networkAddress, Status: "PENDING", TokenID: ""}
+	req := TokenRequest{RequestID: reqID, NetworkAddr: networkAddress, Status: "PENDING", TokenID: "", Purpose: purpose, Sequence: seq}
 	rb, _ := json.Marshal(req)
 	return ctx.GetStub().PutState(reqID, rb)
 }
@@ -196,7 +295,8 @@ func (s *SmartContract) GetPendingTokenRequests(ctx contractapi.TransactionConte
 	return list, nil
 }
 
-// ApproveTokenRequest admin approves token request and assigns token
+// ApproveTokenRequest admin approves token request and assigns a token, or waitlists the
+// request until one becomes available
 func (s *SmartContract) ApproveTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress string) error {
 	if err := s.VerifyAdmin(ctx); err != nil {
 		return err
@@ -211,281 +311,318 @@ func (s *SmartContract) ApproveTokenRequest(ctx contractapi.TransactionContextIn
 	if r.Status != "PENDING" {
 		return fmt.Errorf("request already processed")
 	}
-
-	tokenID, err := s.findAvailableToken(ctx)
+	p, err := s.getParticipant(ctx, networkAddress)
 	if err != nil {
 		return err
 	}
-	if tokenID == "" {
-		return fmt.Errorf("no tokens available")
-	}
-
-	r.Status = "APPROVED"
-	r.TokenID = tokenID
-	rb, _ = json.Marshal(r)
-
-	if err = ctx.GetStub().PutState(reqID, rb); err != nil {
+	if err := checkNotFrozen(p); err != nil {
 		return err
 	}
-
-	pb, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || pb == nil {
-		return fmt.Errorf("participant not found")
+	if err := checkVerified(p); err != nil {
+		return err
 	}
-	var p Participant
-	json.Unmarshal(pb, &p)
-	p.TokenID = tokenID
-	p.Approved = true
-	pb, _ = json.Marshal(p)
 
-	if err = ctx.GetStub().PutState(networkAddress, pb); err != nil {
+	tokenID, err := s.findAvailableToken(ctx)
+	if err != nil {
 		return err
 	}
-
-	tb, err := ctx.GetStub().GetState(tokenID)
-	if err != nil || tb == nil {
-		return fmt.Errorf("token not found")
+	if tokenID == "" {
+		return s.addToWaitlist(ctx, &r)
 	}
-	var t Token
-	json.Unmarshal(tb, &t)
-	t.Owner = networkAddress
-	t.Available = false
-	tb, _ = json.Marshal(t)
-
-	return ctx.GetStub().PutState(tokenID, tb)
+	return s.assignToken(ctx, &r, p, tokenID)
 }
 
-// findAvailableToken returns first available tokenID or empty string
+// findAvailableToken returns the first tokenID in the pool index or empty string
 func (s *SmartContract) findAvailableToken(ctx contractapi.TransactionContextInterface) (string, error) {
-	for i := 1; i <= maxTokens; i++ {
-		tid := fmt.Sprintf("token_%d", i)
-		b, err := ctx.GetStub().GetState(tid)
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(poolObjectType, nil)
+	if err != nil {
+		return "", err
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
 		if err != nil {
 			return "", err
 		}
-		if b == nil {
-			continue
-		}
-		var t Token
-		if err := json.Unmarshal(b, &t); err != nil {
+		tid := string(kv.Value)
+		b, err := ctx.GetStub().GetState(tid)
+		if err != nil {
 			return "", err
 		}
-		if t.Available {
+		var t Token
+		if b != nil && json.Unmarshal(b, &t) == nil && t.Available {
 			return tid, nil
 		}
 	}
 	return "", nil
 }
 
-// GetTokenAccess verifies password and returns token address
-func (s *SmartContract) GetTokenAccess(ctx contractapi.TransactionContextInterface, networkAddress, passwordHash string) (string, error) {
-	pb, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || pb == nil {
-		return "", fmt.Errorf("participant not found")
+// GetTokenAccess verifies password and ownership of tokenID and returns token address
+func (s *SmartContract) GetTokenAccess(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash string) (string, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := s.getParticipant(ctx, networkAddress)
+	if err != nil {
+		return "", err
 	}
-	var p Participant
-	json.Unmarshal(pb, &p)
-	if p.PasswordHash != passwordHash {
-		return "", fmt.Errorf("password mismatch")
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return "", err
+	}
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return "", err
 	}
-	if p.TokenID == "" {
+	if len(p.ownedTokenIDs()) == 0 {
 		return "", fmt.Errorf("token not assigned")
 	}
-	return p.TokenID, nil
+	token, err := s.ownedToken(ctx, p, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return token.TokenID, nil
 }
 
 // RequestMintCoins allows token owner to request minting coins
-// RequestMintCoins verifies participant identity and password hash, then stores mint request
-func (s *SmartContract) RequestMintCoins(ctx contractapi.TransactionContextInterface, networkAddress string, passwordHash string, amount int) error {
-	// Fetch participant information by network address
-	partBytes, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || partBytes == nil {
-		return fmt.Errorf("participant not found")
-	}
-	var participant Participant
-	if err := json.Unmarshal(partBytes, &participant); err != nil {
-		return err
+// RequestMintCoins verifies participant identity and password hash, then stores a new mint request
+// for amount coins of tokenID and returns its ID; networkAddress may be empty to use the participant
+// bound to the caller
+func (s *SmartContract) RequestMintCoins(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash, amount string) (string, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
 	}
-
-	// Verify password hash matches stored hash
-	if participant.PasswordHash != passwordHash {
-		return fmt.Errorf("invalid password")
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
 	}
 
-	// Verify caller identity matches participant client ID
-	callerID, err := ctx.GetClientIdentity().GetID()
+	// Fetch participant bound to the caller's client identity
+	participant, err := s.callerParticipant(ctx, networkAddress)
 	if err != nil {
-		return err
+		return "", err
 	}
-	if participant.ClientID != callerID {
-		return fmt.Errorf("unauthorized caller")
+	if err := checkVerified(participant); err != nil {
+		return "", err
 	}
-
-	// Check token ownership
-	tokenBytes, err := ctx.GetStub().GetState(participant.TokenID)
-	if err != nil || tokenBytes == nil {
-		return fmt.Errorf("token not found")
+	if err := checkNotFrozen(participant); err != nil {
+		return "", err
 	}
-	var token Token
-	if err := json.Unmarshal(tokenBytes, &token); err != nil {
-		return err
+	if err := s.loadParticipantPII(ctx, participant); err != nil {
+		return "", err
 	}
-	if token.Owner != networkAddress {
-		return fmt.Errorf("caller is not token owner")
+
+	// Verify password hash matches stored hash
+	if err := verifySecret(participant.PasswordHash, participant.Credential, passwordHash); err != nil {
+		return "", err
 	}
 
-	// Create mint request key unique per token and participant
-	reqKey := fmt.Sprintf("mintrequest_%s_%s", participant.TokenID, networkAddress)
-	mintReq := MintRequest{
-		RequestID:   reqKey,
-		TokenID:     participant.TokenID,
-		RequestedBy: networkAddress,
-		Amount:      amount,
-		Approved:    false,
+	// Check token ownership
+	token, err := s.ownedToken(ctx, participant, tokenID)
+	if err != nil {
+		return "", err
 	}
-	reqBytes, err := json.Marshal(mintReq)
+
+	value, err := parsePositiveAmount(amount, token.decimals())
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := s.checkMintLimit(ctx, mintRequestKeyPrefix, token, participant.NetworkAddress, value, ""); err != nil {
+		return "", err
 	}
 
-	// Store the mint request on ledger
-	return ctx.GetStub().PutState(reqKey, reqBytes)
+	mr, err := s.newMintRequest(ctx, mintRequestKeyPrefix, token, participant.NetworkAddress, value)
+	if err != nil {
+		return "", err
+	}
+	return mr.RequestID, nil
 }
 
-// GetPendingMintRequests (admin)
+// GetPendingMintRequests lists the owner mint requests still open for approval (admin)
 func (s *SmartContract) GetPendingMintRequests(ctx contractapi.TransactionContextInterface) ([]MintRequest, error) {
 	if err := s.VerifyAdmin(ctx); err != nil {
 		return nil, err
 	}
-	it, err := ctx.GetStub().GetStateByRange("", "")
+	now, err := txTime(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer it.Close()
-	var reqs []MintRequest
-	for it.HasNext() {
-		kv, _ := it.Next()
-		if strings.HasPrefix(kv.Key, "mintrequest_") {
-			var r MintRequest
-			if json.Unmarshal(kv.Value, &r) == nil && !r.Approved {
-				reqs = append(reqs, r)
-			}
-		}
-	}
-	return reqs, nil
+	return s.listMintRequests(ctx, mintRequestKeyPrefix, func(r *MintRequest) bool { return r.open(now) })
 }
 
-// ApproveMintRequest approves mint request and mints coins
+// ApproveMintRequest records the calling admin's approval and mints the coins once the token's
+// approval policy is met, see SetMintApprovalPolicy
 func (s *SmartContract) ApproveMintRequest(ctx contractapi.TransactionContextInterface, requestID string) error {
 	if err := s.VerifyAdmin(ctx); err != nil {
 		return err
 	}
 
-	reqBytes, err := ctx.GetStub().GetState(requestID)
-	if err != nil || reqBytes == nil {
-		return fmt.Errorf("mint request not found")
-	}
-
-	var mr MintRequest
-	if err := json.Unmarshal(reqBytes, &mr); err != nil {
+	mr, err := s.pendingMintRequest(ctx, mintRequestKeyPrefix, requestID)
+	if err != nil {
 		return err
 	}
-
-	if mr.Approved {
-		return fmt.Errorf("mint request already approved")
+	if err := s.requireNotFrozen(ctx, mr.RequestedBy); err != nil {
+		return err
 	}
 
-	mr.Approved = true
-	updatedReqBytes, err := json.Marshal(mr)
+	token, err := s.getToken(ctx, mr.TokenID)
 	if err != nil {
 		return err
 	}
-
-	if err = ctx.GetStub().PutState(requestID, updatedReqBytes); err != nil {
-		return err
+	if token.Owner != mr.RequestedBy {
+		return fmt.Errorf("requester no longer owns token")
 	}
-
-	tokenBytes, err := ctx.GetStub().GetState(mr.TokenID)
-	if err != nil || tokenBytes == nil {
-		return fmt.Errorf("token not found")
+	if err := token.checkMintAllowed(mr.Amount); err != nil {
+		return err
 	}
-
-	var token Token
-	if err := json.Unmarshal(tokenBytes, &token); err != nil {
+	if err := s.checkMintLimit(ctx, mintRequestKeyPrefix, token, mr.RequestedBy, mr.Amount, mr.RequestID); err != nil {
 		return err
 	}
 
-	token.Minted += mr.Amount
-	updatedTokenBytes, err := json.Marshal(token)
+	quorum, err := s.recordMintApproval(ctx, mr, token.mintApprovalsRequired(mr))
 	if err != nil {
 		return err
 	}
+	if !quorum {
+		return s.putMintRequest(ctx, mr)
+	}
+	mr.close(mintRequestApproved, "")
+	if err := s.putMintRequest(ctx, mr); err != nil {
+		return err
+	}
 
-	return ctx.GetStub().PutState(mr.TokenID, updatedTokenBytes)
+	if token.TotalMinted, err = token.totalMinted().add(mr.Amount); err != nil {
+		return err
+	}
+	if token.Minted, err = token.Minted.add(mr.Amount); err != nil {
+		return err
+	}
+	return s.putToken(ctx, token)
 }
 
-func (s *SmartContract) GetWalletInfo(ctx contractapi.TransactionContextInterface, networkAddress, passwordHash string) (map[string]interface{}, error) {
-	pb, err := ctx.GetStub().GetState(networkAddress)
-	if err != nil || pb == nil {
-		return nil, fmt.Errorf("participant not found")
+// GetWalletInfo returns the wallet of tokenID for the participant bound to the caller;
+// networkAddress may be empty
+func (s *SmartContract) GetWalletInfo(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash string) (map[string]interface{}, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return nil, err
 	}
-	var p Participant
-	json.Unmarshal(pb, &p)
-
-	// Check password hash matches
-	if p.PasswordHash != passwordHash {
-		return nil, fmt.Errorf("incorrect password")
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return nil, err
 	}
 
-	// Verify caller client ID matches participant client ID
-	callerID, err := ctx.GetClientIdentity().GetID()
+	// Resolve participant bound to the caller's client identity
+	p, err := s.callerParticipant(ctx, networkAddress)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get caller identity: %v", err)
+		return nil, err
 	}
-	if p.ClientID != callerID {
-		return nil, fmt.Errorf("unauthorized caller")
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return nil, err
 	}
 
-	tb, err := ctx.GetStub().GetState(p.TokenID)
-	if err != nil || tb == nil {
-		return nil, fmt.Errorf("token not found")
+	// Check password hash matches
+	if err := verifySecret(p.PasswordHash, p.Credential, passwordHash); err != nil {
+		return nil, err
+	}
+
+	t, err := s.ownedToken(ctx, p, tokenID)
+	if err != nil {
+		return nil, err
 	}
-	var t Token
-	json.Unmarshal(tb, &t)
 	return map[string]interface{}{
-		"networkAddress":   p.NetworkAddress,
-		"tokenID":          t.TokenID,
-		"mintedCoins":      t.Minted,
-		"tokenTransferIDs": t.TransferIDs,
+		"networkAddress":    p.NetworkAddress,
+		"tokenIDs":          p.ownedTokenIDs(),
+		"tokenID":           t.TokenID,
+		"decimals":          t.decimals(),
+		"mintedCoins":       t.Minted,
+		"totalMinted":       t.totalMinted(),
+		"maxSupply":         t.MaxSupply,
+		"maxMintPerRequest": t.MaxMintPerRequest,
+		"burned":            t.Burned,
+		"tokenTransferIDs":  t.TransferIDs,
 	}, nil
 }
 
-// Customer can view all tokens available to select (user function)
-func (s *SmartContract) ViewAllTokens(ctx contractapi.TransactionContextInterface) ([]Token, error) {
+// TokenListing is what customers see when choosing a token
+type TokenListing struct {
+	TokenID           string         `json:"token_id"`
+	Owner             string         `json:"owner"`
+	Available         bool           `json:"available"`
+	Minted            Amount         `json:"minted"`
+	TotalMinted       Amount         `json:"total_minted"`
+	MaxSupply         Amount         `json:"max_supply"`
+	MaxMintPerRequest Amount         `json:"max_mint_per_request"`
+	Burned            Amount         `json:"burned"`
+	Metadata          *TokenMetadata `json:"metadata,omitempty"`
+	Paused            bool           `json:"paused"`
+	CustomerCount     int            `json:"customer_count"` // approved customers
+}
+
+// ViewAllTokens lists all tokens with their metadata and approved customer counts
+func (s *SmartContract) ViewAllTokens(ctx contractapi.TransactionContextInterface) ([]TokenListing, error) {
+	if err := s.requirePermission(ctx, permReadPublic); err != nil {
+		return nil, err
+	}
 	iter, err := ctx.GetStub().GetStateByRange("", "")
 	if err != nil {
 		return nil, err
 	}
 	defer iter.Close()
 
-	var tokens []Token
+	var tokens []TokenListing
+	customers := make(map[string]int)
 	for iter.HasNext() {
 		kv, err := iter.Next()
 		if err != nil {
 			return nil, err
 		}
-		if strings.HasPrefix(kv.Key, "token_") {
+		switch {
+		case strings.HasPrefix(kv.Key, "token_"):
 			var token Token
 			if err := json.Unmarshal(kv.Value, &token); err == nil {
-				tokens = append(tokens, token)
+				tokens = append(tokens, TokenListing{
+					TokenID:           token.TokenID,
+					Owner:             token.Owner,
+					Available:         token.Available,
+					Minted:            token.Minted,
+					TotalMinted:       token.totalMinted(),
+					MaxSupply:         token.MaxSupply,
+					MaxMintPerRequest: token.MaxMintPerRequest,
+					Burned:            token.Burned,
+					Metadata:          token.Metadata,
+					Paused:            token.Paused,
+				})
+			}
+		case strings.HasPrefix(kv.Key, "customer_"):
+			var cust Customer
+			if err := json.Unmarshal(kv.Value, &cust); err == nil && cust.Approved && !cust.Frozen {
+				customers[cust.TokenID]++
 			}
 		}
 	}
+	for i := range tokens {
+		tokens[i].CustomerCount = customers[tokens[i].TokenID]
+	}
 	return tokens, nil
 }
 
 // Customer registers for a token (recorded as pending for token owner approval)
 func (s *SmartContract) RegisterCustomer(ctx contractapi.TransactionContextInterface, networkAddress, name, passwordHash, tokenID string) error {
+	if err := s.requirePermission(ctx, permTransact); err != nil {
+		return err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return err
+	}
+	if name, err = s.secretInput(ctx, transientNameKey, name); err != nil {
+		return err
+	}
+
 	// Check token exists and approved
 	tokenBytes, err := ctx.GetStub().GetState(tokenID)
 	if err != nil || tokenBytes == nil {
@@ -495,36 +632,55 @@ func (s *SmartContract) RegisterCustomer(ctx contractapi.TransactionContextInter
 	if err := json.Unmarshal(tokenBytes, &token); err != nil || token.Owner == "" {
 		return fmt.Errorf("invalid or unowned token")
 	}
+	if err := checkNotPaused(&token); err != nil {
+		return err
+	}
+	if err := s.requireTokenOwnerNotFrozen(ctx, tokenID); err != nil {
+		return err
+	}
 
 	// Build request id composite key
 	reqID := "custreq_" + networkAddress + "_" + tokenID
 
-	// Prevent duplicate request
+	// Prevent duplicate request; requests cancelled by a token revocation may be replaced
 	existsBytes, err := ctx.GetStub().GetState(reqID)
 	if err != nil {
 		return err
 	}
 	if existsBytes != nil {
-		return fmt.Errorf("customer registration request already exists")
+		var existing RegisterCustomerRequest
+		if json.Unmarshal(existsBytes, &existing) != nil || !existing.Cancelled {
+			return fmt.Errorf("customer registration request already exists")
+		}
+	}
+
+	cred, err := newPasswordCredential(ctx, passwordHash, "customer_"+networkAddress+"_"+tokenID)
+	if err != nil {
+		return err
 	}
 
 	req := RegisterCustomerRequest{
 		RequestID:      reqID,
 		NetworkAddress: networkAddress,
 		Name:           name,
-		PasswordHash:   passwordHash,
+		Credential:     cred,
 		TokenID:        tokenID,
 		Approved:       false,
 	}
-	requestBytes, err := json.Marshal(req)
-	if err != nil {
-		return err
-	}
-	return ctx.GetStub().PutState(reqID, requestBytes)
+	return s.putCustomerRequest(ctx, &req, true)
 }
 
-// Token owner views pending customer registrations for their token
+// Token owner views pending customer registrations for their token;
+// ownerNetworkAddress is optional, the owner is resolved from the caller's identity
 func (s *SmartContract) ViewPendingCustomerRegistrations(ctx contractapi.TransactionContextInterface, tokenID, ownerNetworkAddress string) ([]RegisterCustomerRequest, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, err
+	}
+	owner, err := s.callerParticipant(ctx, ownerNetworkAddress)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify caller is owner of tokenID
 	tokenBytes, err := ctx.GetStub().GetState(tokenID)
 	if err != nil || tokenBytes == nil {
@@ -534,7 +690,7 @@ func (s *SmartContract) ViewPendingCustomerRegistrations(ctx contractapi.Transac
 	if err := json.Unmarshal(tokenBytes, &token); err != nil {
 		return nil, err
 	}
-	if token.Owner != ownerNetworkAddress {
+	if token.Owner != owner.NetworkAddress {
 		return nil, fmt.Errorf("caller is not token owner")
 	}
 
@@ -552,7 +708,11 @@ func (s *SmartContract) ViewPendingCustomerRegistrations(ctx contractapi.Transac
 		}
 		if strings.HasPrefix(kv.Key, "custreq_") {
 			var req RegisterCustomerRequest
-			if err := json.Unmarshal(kv.Value, &req); err == nil && req.TokenID == tokenID && !req.Approved {
+			if err := json.Unmarshal(kv.Value, &req); err == nil && req.TokenID == tokenID && !req.Approved && !req.Cancelled {
+				if err := s.loadCustomerRequestPII(ctx, &req); err != nil {
+					return nil, err
+				}
+				req.clearSecrets()
 				pendingRequests = append(pendingRequests, req)
 			}
 		}
@@ -560,14 +720,24 @@ func (s *SmartContract) ViewPendingCustomerRegistrations(ctx contractapi.Transac
 	return pendingRequests, nil
 }
 
-// Token owner approves customer registration
+// Token owner approves customer registration; ownerNetworkAddress is optional
 func (s *SmartContract) ApproveCustomerRegistration(ctx contractapi.TransactionContextInterface, requestID, ownerNetworkAddress string) error {
-	reqBytes, err := ctx.GetStub().GetState(requestID)
-	if err != nil || reqBytes == nil {
-		return fmt.Errorf("customer registration request not found")
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return err
+	}
+	owner, err := s.callerParticipant(ctx, ownerNetworkAddress)
+	if err != nil {
+		return err
+	}
+	if err := checkVerified(owner); err != nil {
+		return err
+	}
+	if err := checkNotFrozen(owner); err != nil {
+		return err
 	}
-	var req RegisterCustomerRequest
-	if err := json.Unmarshal(reqBytes, &req); err != nil {
+
+	req, err := s.getCustomerRequest(ctx, requestID)
+	if err != nil {
 		return err
 	}
 
@@ -580,17 +750,19 @@ func (s *SmartContract) ApproveCustomerRegistration(ctx contractapi.TransactionC
 	if err := json.Unmarshal(tokenBytes, &token); err != nil {
 		return err
 	}
-	if token.Owner != ownerNetworkAddress {
+	if token.Owner != owner.NetworkAddress {
 		return fmt.Errorf("caller is not token owner")
 	}
 	if req.Approved {
 		return fmt.Errorf("already approved")
 	}
+	if req.Cancelled {
+		return fmt.Errorf("customer registration request cancelled")
+	}
 
 	// Approve customer registration and create customer wallet entry
 	req.Approved = true
-	updatedReqBytes, _ := json.Marshal(req)
-	if err := ctx.GetStub().PutState(requestID, updatedReqBytes); err != nil {
+	if err := s.putCustomerRequest(ctx, req, false); err != nil {
 		return err
 	}
 
@@ -598,42 +770,68 @@ func (s *SmartContract) ApproveCustomerRegistration(ctx contractapi.TransactionC
 		NetworkAddress: req.NetworkAddress,
 		Name:           req.Name,
 		PasswordHash:   req.PasswordHash,
+		Credential:     req.Credential,
 		TokenID:        req.TokenID,
 		Approved:       true,
-		Balance:        0,
+		Balance:        zeroAmount,
 	}
-	customerBytes, _ := json.Marshal(customer)
 	customerKey := "customer_" + req.NetworkAddress + "_" + req.TokenID
-	return ctx.GetStub().PutState(customerKey, customerBytes)
+	return s.putCustomer(ctx, customerKey, &customer, true)
 }
 
-// Customer requests coins minting (referenced by token and customer)
-func (s *SmartContract) CustomerRequestMint(ctx contractapi.TransactionContextInterface, networkAddress, tokenID string, amount int) error {
+// Customer requests amount coins minting on the token; returns the new request ID
+func (s *SmartContract) CustomerRequestMint(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, amount string) (string, error) {
+	if err := s.requirePermission(ctx, permTransact); err != nil {
+		return "", err
+	}
 	customerKey := "customer_" + networkAddress + "_" + tokenID
 	customerBytes, err := ctx.GetStub().GetState(customerKey)
 	if err != nil || customerBytes == nil {
-		return fmt.Errorf("customer not registered or approved for token")
+		return "", fmt.Errorf("customer not registered or approved for token")
 	}
 	var customer Customer
 	if err := json.Unmarshal(customerBytes, &customer); err != nil {
-		return err
+		return "", err
+	}
+	if !customer.Approved || customer.Frozen {
+		return "", fmt.Errorf("customer not registered or approved for token")
+	}
+	if err := s.requireTokenOwnerNotFrozen(ctx, tokenID); err != nil {
+		return "", err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkNotPaused(token); err != nil {
+		return "", err
+	}
+	value, err := parsePositiveAmount(amount, token.decimals())
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkMintLimit(ctx, customerMintRequestKeyPrefix, token, customer.NetworkAddress, value, ""); err != nil {
+		return "", err
 	}
 
-	// Create mint request with unique key
-	requestID := fmt.Sprintf("custmintreq_%s_%s", customer.NetworkAddress, tokenID)
-	mintReq := MintRequest{
-		RequestID:   requestID,
-		TokenID:     tokenID,
-		RequestedBy: customer.NetworkAddress,
-		Amount:      amount,
-		Approved:    false,
+	mintReq, err := s.newMintRequest(ctx, customerMintRequestKeyPrefix, token, customer.NetworkAddress, value)
+	if err != nil {
+		return "", err
 	}
-	reqBytes, _ := json.Marshal(mintReq)
-	return ctx.GetStub().PutState(requestID, reqBytes)
+	return mintReq.RequestID, nil
 }
 
-// Token owner views pending mint requests for their token from customers
+// Token owner views pending mint requests for their token from customers;
+// ownerNetworkAddress is optional, the owner is resolved from the caller's identity
 func (s *SmartContract) ViewPendingCustomerMintRequests(ctx contractapi.TransactionContextInterface, tokenID, ownerNetworkAddress string) ([]MintRequest, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, err
+	}
+	owner, err := s.callerParticipant(ctx, ownerNetworkAddress)
+	if err != nil {
+		return nil, err
+	}
+
 	tokenBytes, err := ctx.GetStub().GetState(tokenID)
 	if err != nil || tokenBytes == nil {
 		return nil, fmt.Errorf("token not found")
@@ -642,42 +840,40 @@ func (s *SmartContract) ViewPendingCustomerMintRequests(ctx contractapi.Transact
 	if err := json.Unmarshal(tokenBytes, &token); err != nil {
 		return nil, err
 	}
-	if token.Owner != ownerNetworkAddress {
+	if token.Owner != owner.NetworkAddress {
 		return nil, fmt.Errorf("caller is not token owner")
 	}
 
-	iter, err := ctx.GetStub().GetStateByRange("", "")
+	now, err := txTime(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer iter.Close()
-
-	var pending []MintRequest
-	for iter.HasNext() {
-		kv, err := iter.Next()
-		if err != nil {
-			return nil, err
-		}
-		if strings.HasPrefix(kv.Key, "custmintreq_") {
-			var r MintRequest
-			if err := json.Unmarshal(kv.Value, &r); err == nil && r.TokenID == tokenID && !r.Approved {
-				pending = append(pending, r)
-			}
-		}
-	}
-	return pending, nil
+	return s.listMintRequests(ctx, customerMintRequestKeyPrefix, func(r *MintRequest) bool {
+		return r.TokenID == tokenID && r.open(now)
+	})
 }
 
 // Token owner approves customer mint request, increasing customer balance
-// Token owner approves customer mint request, increasing customer balance if token has sufficient minted coins
+// Token owner approves customer mint request, increasing customer balance if token has sufficient minted coins;
+// ownerNetworkAddress is optional, the owner is resolved from the caller's identity
 func (s *SmartContract) ApproveCustomerMint(ctx contractapi.TransactionContextInterface, requestID, ownerNetworkAddress string) error {
-	// Retrieve the mint request by ID
-	reqBytes, err := ctx.GetStub().GetState(requestID)
-	if err != nil || reqBytes == nil {
-		return fmt.Errorf("mint request not found")
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return err
+	}
+	owner, err := s.callerParticipant(ctx, ownerNetworkAddress)
+	if err != nil {
+		return err
+	}
+	if err := checkVerified(owner); err != nil {
+		return err
 	}
-	var mintReq MintRequest
-	if err := json.Unmarshal(reqBytes, &mintReq); err != nil {
+	if err := checkNotFrozen(owner); err != nil {
+		return err
+	}
+
+	// Retrieve the mint request by ID
+	mintReq, err := s.pendingMintRequest(ctx, customerMintRequestKeyPrefix, requestID)
+	if err != nil {
 		return err
 	}
 
@@ -692,32 +888,33 @@ func (s *SmartContract) ApproveCustomerMint(ctx contractapi.TransactionContextIn
 	}
 
 	// Check that caller is indeed token owner
-	if token.Owner != ownerNetworkAddress {
+	if token.Owner != owner.NetworkAddress {
 		return fmt.Errorf("caller is not token owner")
 	}
-
-	// Check if request already approved
-	if mintReq.Approved {
-		return fmt.Errorf("mint request already approved")
+	if err := checkNotPaused(&token); err != nil {
+		return err
 	}
 
 	// Check if the token has enough minted coins to fulfill this request
-	if token.Minted < mintReq.Amount {
-		return fmt.Errorf("insufficient minted coin balance on token: available %d, requested %d", token.Minted, mintReq.Amount)
+	if token.Minted.cmp(mintReq.Amount) < 0 {
+		d := token.decimals()
+		return fmt.Errorf("insufficient minted coin balance on token: available %s, requested %s", token.Minted.format(d), mintReq.Amount.format(d))
 	}
-
-	// Approve mint request
-	mintReq.Approved = true
-	updatedReqBytes, err := json.Marshal(mintReq)
-	if err != nil {
+	if err := s.checkMintLimit(ctx, customerMintRequestKeyPrefix, &token, mintReq.RequestedBy, mintReq.Amount, mintReq.RequestID); err != nil {
 		return err
 	}
-	if err := ctx.GetStub().PutState(requestID, updatedReqBytes); err != nil {
+
+	// Approve mint request
+	mintReq.close(mintRequestApproved, "")
+	if err := s.putMintRequest(ctx, mintReq); err != nil {
 		return err
 	}
 
 	// Deduct the requested amount from token's minted coins balance
-	token.Minted -= mintReq.Amount
+	token.TotalMinted = token.totalMinted()
+	if token.Minted, err = token.Minted.sub(mintReq.Amount); err != nil {
+		return err
+	}
 	updatedTokenBytes, err := json.Marshal(token)
 	if err != nil {
 		return err
@@ -728,53 +925,100 @@ func (s *SmartContract) ApproveCustomerMint(ctx contractapi.TransactionContextIn
 
 	// Credit the customer’s balance
 	customerKey := "customer_" + mintReq.RequestedBy + "_" + mintReq.TokenID
-	custBytes, err := ctx.GetStub().GetState(customerKey)
-	if err != nil || custBytes == nil {
-		return fmt.Errorf("customer not found")
-	}
-	var cust Customer
-	if err := json.Unmarshal(custBytes, &cust); err != nil {
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
 		return err
 	}
-	cust.Balance += mintReq.Amount
-	updatedCustBytes, err := json.Marshal(cust)
-	if err != nil {
+	if cust.Frozen {
+		return fmt.Errorf("customer balance is frozen")
+	}
+	if cust.Balance, err = cust.Balance.add(mintReq.Amount); err != nil {
 		return err
 	}
-	return ctx.GetStub().PutState(customerKey, updatedCustBytes)
+	return s.putCustomer(ctx, customerKey, cust, false)
 }
 
 // Customer views their subtoken wallet info securely
 func (s *SmartContract) ViewCustomerWallet(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash string) (map[string]interface{}, error) {
+	if err := s.requirePermission(ctx, permTransact); err != nil {
+		return nil, err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
 	customerKey := "customer_" + networkAddress + "_" + tokenID
-	custBytes, err := ctx.GetStub().GetState(customerKey)
-	if err != nil || custBytes == nil {
-		return nil, fmt.Errorf("customer not found")
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
+		return nil, err
 	}
-	var cust Customer
-	if err := json.Unmarshal(custBytes, &cust); err != nil {
+	if err := s.loadCustomerPII(ctx, customerKey, cust); err != nil {
 		return nil, err
 	}
-	if cust.PasswordHash != passwordHash {
-		return nil, fmt.Errorf("invalid password")
+	if err := verifySecret(cust.PasswordHash, cust.Credential, passwordHash); err != nil {
+		return nil, err
 	}
 	return map[string]interface{}{
 		"networkAddress":         cust.NetworkAddress,
 		"tokenID":                cust.TokenID,
 		"balance":                cust.Balance,
+		"redeeming":              cust.Redeeming,
 		"approved":               cust.Approved,
+		"frozen":                 cust.Frozen,
 		"participantTransferIDs": cust.TransferIDs,
 		"tokenTransferIDs":       cust.TransferIDs,
 	}, nil
 }
 
-// 1. CreateTransferRequest - generates unique ID and submits new transfer request
+// 1. CreateTransferRequest - generates unique ID and submits new transfer request from the
+// calling participant; senderParticipantID is optional, the sender is resolved from the caller's identity.
+// The coins come out of the unissued coins of senderTokenTransferID, a token the sender owns.
 func (s *SmartContract) CreateTransferRequest(ctx contractapi.TransactionContextInterface,
 	senderParticipantID, receiverParticipantID, senderTokenTransferID, receiverTokenTransferID, tokenID, amountStr string) (string, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return "", err
+	}
+	sender, err := s.callerParticipant(ctx, senderParticipantID)
+	if err != nil {
+		return "", err
+	}
+	senderParticipantID = sender.NetworkAddress
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err := s.requireTokenOwnerNotFrozen(ctx, tokenID); err != nil {
+		return "", err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkNotPaused(token); err != nil {
+		return "", err
+	}
+	senderToken, err := s.ownedToken(ctx, sender, senderTokenTransferID)
+	if err != nil {
+		return "", err
+	}
+	if senderToken.TokenID == token.TokenID {
+		return "", fmt.Errorf("cannot transfer coins to the sending token")
+	}
+	if err := checkNotPaused(senderToken); err != nil {
+		return "", err
+	}
+	if senderToken.decimals() != token.decimals() {
+		return "", fmt.Errorf("sending and receiving tokens have different decimals")
+	}
+	amount, err := parsePositiveAmount(amountStr, token.decimals())
 	if err != nil {
-		return "", fmt.Errorf("invalid amount: %v", err)
+		return "", err
+	}
+	if senderToken.Minted.cmp(amount) < 0 {
+		return "", fmt.Errorf("insufficient funds for transfer")
+	}
+	for _, addr := range []string{senderParticipantID, receiverParticipantID} {
+		if err := s.requireNotFrozen(ctx, addr); err != nil {
+			return "", err
+		}
 	}
 
 	uuid, err := uuid.NewRandom()
@@ -807,8 +1051,17 @@ func (s *SmartContract) CreateTransferRequest(ctx contractapi.TransactionContext
 	return transferRequestID, nil
 }
 
-// 2. ApproveTransferByOwner - owner approves transfer, sets status to pending receiver approval
+// 2. ApproveTransferByOwner - owner approves transfer, sets status to pending receiver approval;
+// approver is optional, the sender is resolved from the caller's identity
 func (s *SmartContract) ApproveTransferByOwner(ctx contractapi.TransactionContextInterface, transferRequestID, approver string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	caller, err := s.callerParticipant(ctx, approver)
+	if err != nil {
+		return err
+	}
+
 	reqBytes, err := ctx.GetStub().GetState(transferRequestID)
 	if err != nil || reqBytes == nil {
 		return fmt.Errorf("transfer request not found")
@@ -821,17 +1074,35 @@ func (s *SmartContract) ApproveTransferByOwner(ctx contractapi.TransactionContex
 	if request.Status != "PendingOwnerApproval" {
 		return fmt.Errorf("transfer request not pending owner approval")
 	}
-	if request.SenderTransferID != approver {
+	if request.SenderTransferID != caller.NetworkAddress {
 		return fmt.Errorf("approver is not the sender participant")
 	}
+	if err := checkNotFrozen(caller); err != nil {
+		return err
+	}
+	if err := s.requireTokenOwnerNotFrozen(ctx, request.TokenID); err != nil {
+		return err
+	}
+	if err := s.requireTokenNotPaused(ctx, request.TokenID); err != nil {
+		return err
+	}
 
 	request.Status = "PendingReceiverApproval"
 	updatedBytes, _ := json.Marshal(request)
 	return ctx.GetStub().PutState(transferRequestID, updatedBytes)
 }
 
-// 3. ApproveTransferByReceiver - receiver approves; completes or rejects the transfer
+// 3. ApproveTransferByReceiver - receiver approves; completes or rejects the transfer;
+// approver is optional, the receiving token owner is resolved from the caller's identity
 func (s *SmartContract) ApproveTransferByReceiver(ctx contractapi.TransactionContextInterface, transferRequestID, approver string) error {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return err
+	}
+	caller, err := s.callerParticipant(ctx, approver)
+	if err != nil {
+		return err
+	}
+
 	reqBytes, err := ctx.GetStub().GetState(transferRequestID)
 	if err != nil || reqBytes == nil {
 		return fmt.Errorf("transfer request not found")
@@ -854,36 +1125,46 @@ func (s *SmartContract) ApproveTransferByReceiver(ctx contractapi.TransactionCon
 	if err := json.Unmarshal(tokenBytes, &token); err != nil {
 		return err
 	}
-	if token.Owner != approver {
+	if token.Owner != caller.NetworkAddress {
 		return fmt.Errorf("approver is not the token owner (receiver)")
 	}
-
-	// Sender balance check and deduction (participant's balance)
-	senderBalBytes, err := ctx.GetStub().GetState(request.SenderTransferID)
-	if err != nil || senderBalBytes == nil {
-		return fmt.Errorf("sender balance not found")
+	if err := checkNotPaused(&token); err != nil {
+		return err
 	}
-	senderBalance, err := strconv.ParseFloat(string(senderBalBytes), 64)
-	if err != nil {
-		return fmt.Errorf("invalid sender balance")
+	if err := checkNotFrozen(caller); err != nil {
+		return err
 	}
-	if senderBalance < request.Amount {
-		// Reject transfer: insufficient funds
-		request.Status = "Rejected"
-		updatedBytes, _ := json.Marshal(request)
-		_ = ctx.GetStub().PutState(transferRequestID, updatedBytes)
-		return fmt.Errorf("insufficient funds for transfer")
+	if err := s.requireNotFrozen(ctx, request.SenderTransferID); err != nil {
+		return err
 	}
 
-	// Deduct sender balance
-	senderBalance -= request.Amount
-	err = ctx.GetStub().PutState(request.SenderTransferID, []byte(fmt.Sprintf("%f", senderBalance)))
+	// Deduct from the unissued coins of the sender's token
+	senderToken, err := s.getToken(ctx, request.SenderTokenTransferID)
 	if err != nil {
-		return fmt.Errorf("failed to update sender balance")
+		return err
+	}
+	if senderToken.Owner != request.SenderTransferID {
+		return fmt.Errorf("sender no longer owns token %s", senderToken.TokenID)
+	}
+	if err := checkNotPaused(senderToken); err != nil {
+		return err
+	}
+	if senderToken.Minted.cmp(request.Amount) < 0 {
+		return fmt.Errorf("insufficient funds for transfer")
+	}
+	senderToken.TotalMinted = senderToken.totalMinted()
+	if senderToken.Minted, err = senderToken.Minted.sub(request.Amount); err != nil {
+		return err
+	}
+	if err := s.putToken(ctx, senderToken); err != nil {
+		return err
 	}
 
 	// Credit token owner's minted balance (receiver)
-	token.Minted += int(request.Amount)
+	token.TotalMinted = token.totalMinted()
+	if token.Minted, err = token.Minted.add(request.Amount); err != nil {
+		return err
+	}
 	updatedTokenBytes, err := json.Marshal(token)
 	if err != nil {
 		return err
@@ -900,6 +1181,9 @@ func (s *SmartContract) ApproveTransferByReceiver(ctx contractapi.TransactionCon
 
 // 4. ViewTransferRequestsForOwner lists transfers waiting for owner's approval
 func (s *SmartContract) ViewTransferRequestsForOwner(ctx contractapi.TransactionContextInterface, ownerID string) ([]TransferRequest, error) {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return nil, err
+	}
 	queryString := fmt.Sprintf(`{"selector":{"sender_transfer_id":"%s","status":"PendingOwnerApproval"}}`, ownerID)
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -922,6 +1206,9 @@ func (s *SmartContract) ViewTransferRequestsForOwner(ctx contractapi.Transaction
 
 // 5. ViewTransferRequestsForReceiver lists transfers waiting for receiver's approval
 func (s *SmartContract) ViewTransferRequestsForReceiver(ctx contractapi.TransactionContextInterface, receiverID string) ([]TransferRequest, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, err
+	}
 	queryString := fmt.Sprintf(`{"selector":{"receiver_transfer_id":"%s","status":"PendingReceiverApproval"}}`, receiverID)
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -944,6 +1231,9 @@ func (s *SmartContract) ViewTransferRequestsForReceiver(ctx contractapi.Transact
 
 // GetParticipantTransferHistory lists all transfers involving a participant transfer ID as sender or receiver
 func (s *SmartContract) GetParticipantTransferHistory(ctx contractapi.TransactionContextInterface, participantTransferID string) ([]TransferRequest, error) {
+	if err := s.requirePermission(ctx, permReadHistory); err != nil {
+		return nil, err
+	}
 	queryString := fmt.Sprintf(`{"selector":{"$or":[{"sender_transfer_id":"%s"},{"receiver_transfer_id":"%s"}]}}`, participantTransferID, participantTransferID)
 	iterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -967,6 +1257,9 @@ func (s *SmartContract) GetParticipantTransferHistory(ctx contractapi.Transactio
 
 // GetTokenTransferHistory lists all transfers involving a token ID
 func (s *SmartContract) GetTokenTransferHistory(ctx contractapi.TransactionContextInterface, tokenID string) ([]TransferRequest, error) {
+	if err := s.requirePermission(ctx, permReadHistory); err != nil {
+		return nil, err
+	}
 	queryString := fmt.Sprintf(`{"selector":{"token_id":"%s"}}`, tokenID)
 	iterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -980,6 +1273,10 @@ func (s *SmartContract) GetTokenTransferHistory(ctx contractapi.TransactionConte
 		if err != nil {
 			return nil, err
 		}
+		// Mint requests and customers carry a token_id too
+		if !strings.HasPrefix(resp.Key, "transfer_") {
+			continue
+		}
 		var tr TransferRequest
 		if err := json.Unmarshal(resp.Value, &tr); err == nil {
 			transfers = append(transfers, tr)
@@ -990,7 +1287,16 @@ func (s *SmartContract) GetTokenTransferHistory(ctx contractapi.TransactionConte
 
 //owner power
 
+// GetTokenParticipantsAndTransactions lists participants and transfers of a token for its owner;
+// callerTransferID is optional, the owner is resolved from the caller's identity
 func (s *SmartContract) GetTokenParticipantsAndTransactions(ctx contractapi.TransactionContextInterface, tokenID string, callerTransferID string) (map[string]interface{}, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, err
+	}
+	caller, err := s.callerParticipant(ctx, callerTransferID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Verify caller is token owner
 	tokenBytes, err := ctx.GetStub().GetState(tokenID)
@@ -1001,7 +1307,7 @@ func (s *SmartContract) GetTokenParticipantsAndTransactions(ctx contractapi.Tran
 	if err := json.Unmarshal(tokenBytes, &token); err != nil {
 		return nil, err
 	}
-	if token.Owner != callerTransferID {
+	if token.Owner != caller.NetworkAddress {
 		return nil, fmt.Errorf("access denied: caller is not the token owner")
 	}
 
@@ -1021,6 +1327,7 @@ func (s *SmartContract) GetTokenParticipantsAndTransactions(ctx contractapi.Tran
 		}
 		var p Participant
 		if err := json.Unmarshal(pResp.Value, &p); err == nil {
+			p.clearSecrets() // legacy records still carry their password inline
 			participants = append(participants, p)
 		}
 	}
@@ -1045,6 +1352,9 @@ func (s *SmartContract) GetTokenParticipantsAndTransactions(ctx contractapi.Tran
 
 // Reuse or redefine this helper function from previous answers
 func (s *SmartContract) GetParticipantTransferHistorybyowner(ctx contractapi.TransactionContextInterface, participantTransferID string) ([]TransferRequest, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, err
+	}
 	queryString := fmt.Sprintf(`{"selector":{"$or":[{"sender_transfer_id":"%s"},{"receiver_transfer_id":"%s"}]}}`, participantTransferID, participantTransferID)
 	iterator, err := ctx.GetStub().GetQueryResult(queryString)
 	if err != nil {
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ownedTokenIDs returns the tokens the participant owns, including the single token_id of
+// records written before participants could own several tokens
+func (p *Participant) ownedTokenIDs() []string {
+	if p.TokenID == "" {
+		return p.TokenIDs
+	}
+	for _, id := range p.TokenIDs {
+		if id == p.TokenID {
+			return p.TokenIDs
+		}
+	}
+	return append([]string{p.TokenID}, p.TokenIDs...)
+}
+
+func (p *Participant) ownsToken(tokenID string) bool {
+	for _, id := range p.ownedTokenIDs() {
+		if id == tokenID {
+			return true
+		}
+	}
+	return false
+}
+
+// addOwnedToken records tokenID as owned by the participant
+func (p *Participant) addOwnedToken(tokenID string) {
+	owned := p.ownedTokenIDs()
+	if !p.ownsToken(tokenID) {
+		owned = append(owned, tokenID)
+	}
+	p.TokenIDs = owned
+	p.TokenID = ""
+	p.Approved = true
+}
+
+// removeOwnedToken drops tokenID; the participant stays approved while it owns any token
+func (p *Participant) removeOwnedToken(tokenID string) {
+	remaining := []string{}
+	for _, id := range p.ownedTokenIDs() {
+		if id != tokenID {
+			remaining = append(remaining, id)
+		}
+	}
+	p.TokenIDs = remaining
+	p.TokenID = ""
+	p.Approved = len(remaining) > 0
+}
+
+// ownedToken loads tokenID, which the participant must own
+func (s *SmartContract) ownedToken(ctx contractapi.TransactionContextInterface, p *Participant, tokenID string) (*Token, error) {
+	if tokenID == "" {
+		return nil, fmt.Errorf("token id is required")
+	}
+	if !p.ownsToken(tokenID) {
+		return nil, fmt.Errorf("caller is not token owner")
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token.Owner != p.NetworkAddress {
+		return nil, fmt.Errorf("caller is not token owner")
+	}
+	return token, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	symbolKeyPrefix        = "symbol_"
+	maxTokenNameLength     = 64
+	maxTokenDescriptionLen = 512
+	maxTokenDecimals       = 18
+)
+
+var (
+	tokenSymbolPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,10}$`)
+	logoHashPattern    = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// TokenMetadata is the issuer profile shown to customers choosing a token
+type TokenMetadata struct {
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Decimals    int    `json:"decimals"`
+	Description string `json:"description"`
+	LogoHash    string `json:"logo_hash"` // hex sha256 of the logo image
+	TermsURL    string `json:"terms_url"`
+}
+
+func (m *TokenMetadata) validate() error {
+	if m.Name == "" || len(m.Name) > maxTokenNameLength {
+		return fmt.Errorf("token name must be 1 to %d characters", maxTokenNameLength)
+	}
+	if !tokenSymbolPattern.MatchString(m.Symbol) {
+		return fmt.Errorf("token symbol must be 2 to 11 upper-case letters or digits, starting with a letter")
+	}
+	if m.Decimals < 0 || m.Decimals > maxTokenDecimals {
+		return fmt.Errorf("decimals must be between 0 and %d", maxTokenDecimals)
+	}
+	if len(m.Description) > maxTokenDescriptionLen {
+		return fmt.Errorf("description must be at most %d characters", maxTokenDescriptionLen)
+	}
+	if m.LogoHash != "" && !logoHashPattern.MatchString(m.LogoHash) {
+		return fmt.Errorf("logo hash must be a lower-case hex sha256")
+	}
+	if m.TermsURL != "" && !strings.HasPrefix(m.TermsURL, "https://") {
+		return fmt.Errorf("terms URL must use https")
+	}
+	return nil
+}
+
+// checkDecimalsChange refuses to change the decimals of a token once amounts were recorded
+// in its minor units, on the token or on any record naming it
+func (s *SmartContract) checkDecimalsChange(ctx contractapi.TransactionContextInterface, t *Token, m *TokenMetadata) error {
+	if m.Decimals == t.decimals() {
+		return nil
+	}
+	if !t.totalMinted().isZero() || !t.MaxSupply.isZero() || !t.MaxMintPerRequest.isZero() || !t.MintApprovalThreshold.isZero() {
+		return fmt.Errorf("decimals cannot change once coins are minted or capped")
+	}
+	recorded, err := s.amountsRecorded(ctx, t.TokenID)
+	if err != nil {
+		return err
+	}
+	if recorded {
+		return fmt.Errorf("decimals cannot change once amounts are recorded for the token")
+	}
+	return nil
+}
+
+// amountsRecorded reports whether a request, limit, customer or other record holding amounts
+// names tokenID, see amountFields
+func (s *SmartContract) amountsRecorded(ctx contractapi.TransactionContextInterface, tokenID string) (bool, error) {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return false, err
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return false, err
+		}
+		if strings.HasPrefix(kv.Key, tokenIDPrefix) || (recordAmountFields(kv.Key) == nil && !strings.HasPrefix(kv.Key, mintLimitKeyPrefix)) {
+			continue
+		}
+		var r struct {
+			TokenID         string `json:"token_id"`
+			SenderTokenID   string `json:"sender_token_transfer_id"`
+			ReceiverTokenID string `json:"receiver_token_transfer_id"`
+		}
+		if json.Unmarshal(kv.Value, &r) != nil {
+			continue
+		}
+		if r.TokenID == tokenID || r.SenderTokenID == tokenID || r.ReceiverTokenID == tokenID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func symbolKey(symbol string) string {
+	return symbolKeyPrefix + symbol
+}
+
+// symbolOwner returns the token holding symbol, or empty string
+func symbolOwner(ctx contractapi.TransactionContextInterface, symbol string) (string, error) {
+	b, err := ctx.GetStub().GetState(symbolKey(symbol))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// getToken reads the token stored under tokenID
+func (s *SmartContract) getToken(ctx contractapi.TransactionContextInterface, tokenID string) (*Token, error) {
+	tb, err := ctx.GetStub().GetState(tokenID)
+	if err != nil || tb == nil {
+		return nil, fmt.Errorf("token not found")
+	}
+	var t Token
+	if err := json.Unmarshal(tb, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *SmartContract) putToken(ctx contractapi.TransactionContextInterface, t *Token) error {
+	tb, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(t.TokenID, tb)
+}
+
+// UpdateTokenMetadata lets the token owner describe its token. Changes that keep the current
+// symbol apply at once; a new symbol waits for an admin to check it is unique.
+func (s *SmartContract) UpdateTokenMetadata(ctx contractapi.TransactionContextInterface, tokenID, name, symbol string, decimals int, description, logoHash, termsURL string) error {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return err
+	}
+	owner, err := s.callerParticipant(ctx, "")
+	if err != nil {
+		return err
+	}
+	if err := checkNotFrozen(owner); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if token.Owner != owner.NetworkAddress {
+		return fmt.Errorf("caller is not token owner")
+	}
+
+	m := &TokenMetadata{Name: name, Symbol: strings.ToUpper(symbol), Decimals: decimals, Description: description, LogoHash: logoHash, TermsURL: termsURL}
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if err := s.checkDecimalsChange(ctx, token, m); err != nil {
+		return err
+	}
+
+	token.MetadataRejection = ""
+	if token.Metadata != nil && token.Metadata.Symbol == m.Symbol {
+		token.Metadata = m
+		token.PendingMetadata = nil
+		return s.putToken(ctx, token)
+	}
+	holder, err := symbolOwner(ctx, m.Symbol)
+	if err != nil {
+		return err
+	}
+	if holder != "" && holder != tokenID {
+		return fmt.Errorf("token symbol %s already in use", m.Symbol)
+	}
+	token.PendingMetadata = m
+	return s.putToken(ctx, token)
+}
+
+// GetPendingTokenMetadata lists tokens whose metadata waits for symbol review
+func (s *SmartContract) GetPendingTokenMetadata(ctx contractapi.TransactionContextInterface) ([]Token, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var pending []Token
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, tokenIDPrefix) {
+			var t Token
+			if err := json.Unmarshal(kv.Value, &t); err == nil && t.PendingMetadata != nil {
+				pending = append(pending, t)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// ApproveTokenMetadata applies pending metadata and reserves its symbol for the token
+func (s *SmartContract) ApproveTokenMetadata(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if token.PendingMetadata == nil {
+		return fmt.Errorf("no pending metadata for token")
+	}
+	if err := s.checkDecimalsChange(ctx, token, token.PendingMetadata); err != nil {
+		return err
+	}
+
+	symbol := token.PendingMetadata.Symbol
+	holder, err := symbolOwner(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if holder != "" && holder != tokenID {
+		return fmt.Errorf("token symbol %s already in use", symbol)
+	}
+	if token.Metadata != nil && token.Metadata.Symbol != symbol {
+		if err := ctx.GetStub().DelState(symbolKey(token.Metadata.Symbol)); err != nil {
+			return err
+		}
+	}
+	if err := ctx.GetStub().PutState(symbolKey(symbol), []byte(tokenID)); err != nil {
+		return err
+	}
+
+	token.Metadata = token.PendingMetadata
+	token.PendingMetadata = nil
+	return s.putToken(ctx, token)
+}
+
+// RejectTokenMetadata discards pending metadata, leaving the reason for the owner
+func (s *SmartContract) RejectTokenMetadata(ctx contractapi.TransactionContextInterface, tokenID, reason string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if token.PendingMetadata == nil {
+		return fmt.Errorf("no pending metadata for token")
+	}
+	token.PendingMetadata = nil
+	token.MetadataRejection = reason
+	return s.putToken(ctx, token)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCustomer registers customer address addr on tokenID with password custpass and has the
+// token owner, acting as ownerID, approve it; the identity is left as the customer
+func setupCustomer(t *testing.T, h *TestHelper, ownerID, addr, tokenID string) {
+	h.SetIdentity(addr+"-id", "Org1MSP", "customer")
+	assert.NoError(t, h.Contract.RegisterCustomer(h.Ctx, addr, "Customer "+addr, "custpass", tokenID))
+	h.SetIdentity(ownerID, "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveCustomerRegistration(h.Ctx, "custreq_"+addr+"_"+tokenID, ""))
+	h.SetIdentity(addr+"-id", "Org1MSP", "customer")
+}
+
+func TestCustomerMintAllowanceAccess(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	setupTokenOwner(t, h, "bob-id", "Bob")
+	setupCustomer(t, h, "alice-id", "carol", tokenID)
+	setupCustomer(t, h, "alice-id", "dave", tokenID)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.SetCustomerMintLimits(h.Ctx, "carol", tokenID, "10", "0"))
+
+	// Other customers cannot read it without carol's password
+	h.SetIdentity("dave-id", "Org1MSP", "customer")
+	_, err := h.Contract.GetRemainingMintAllowance(h.Ctx, "carol", tokenID)
+	assert.Error(t, err)
+	h.SetTransient(map[string]string{transientPasswordKey: "wrong"})
+	_, err = h.Contract.GetRemainingMintAllowance(h.Ctx, "carol", tokenID)
+	assert.Error(t, err)
+
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	h.SetTransient(map[string]string{transientPasswordKey: "custpass"})
+	allowance, err := h.Contract.GetRemainingMintAllowance(h.Ctx, "carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("10"), allowance.DailyRemaining)
+	h.SetTransient(nil)
+
+	// Owners of other tokens are refused, the token owner and admins are not
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.GetRemainingMintAllowance(h.Ctx, "carol", tokenID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.GetRemainingMintAllowance(h.Ctx, "carol", tokenID)
+	assert.NoError(t, err)
+	h.SetIdentity("test-client-id", "Org1MSP", "admin")
+	_, err = h.Contract.GetRemainingMintAllowance(h.Ctx, "carol", tokenID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// MintApproval is one admin's sign-off on a mint request or admin proposal
+type MintApproval struct {
+	ClientID   string `json:"client_id"`
+	MSPID      string `json:"msp_id"`
+	ApprovedAt string `json:"approved_at"`
+}
+
+// MintApprovals is the approval state of a mint request, see GetMintApprovals
+type MintApprovals struct {
+	RequestID string         `json:"request_id"`
+	TokenID   string         `json:"token_id"`
+	Amount    Amount         `json:"amount"`
+	Status    string         `json:"status"`
+	Required  int            `json:"required"` // distinct admins needed before the coins are minted
+	Approvals []MintApproval `json:"approvals"`
+}
+
+// approvalsRequired is how many distinct admins must approve a mint of amount on the token
+func (t *Token) approvalsRequired(amount Amount) int {
+	if t.MintApprovalsRequired > 1 && amount.cmp(t.MintApprovalThreshold) > 0 {
+		return t.MintApprovalsRequired
+	}
+	return 1
+}
+
+// mintApprovalsRequired is how many distinct admins must approve r: what the policy required
+// when r was filed, or more if the policy has since been tightened
+func (t *Token) mintApprovalsRequired(r *MintRequest) int {
+	required := t.approvalsRequired(r.Amount)
+	if r.ApprovalsRequired > required {
+		return r.ApprovalsRequired
+	}
+	return required
+}
+
+// SetMintApprovalPolicy makes mints of more than threshold coins on tokenID wait for approvals
+// from required distinct admins, at least 2; an empty threshold with required 0 removes the
+// policy. The change takes effect once a quorum of admins has called it with the same
+// arguments, see AddAdmin (admin)
+func (s *SmartContract) SetMintApprovalPolicy(ctx contractapi.TransactionContextInterface, tokenID, threshold string, required int) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	var limit Amount
+	switch {
+	case threshold == "" && required == 0:
+	case required < 2:
+		return fmt.Errorf("a mint approval threshold needs at least 2 approvals; clear the policy with an empty threshold and 0")
+	default:
+		if limit, err = parseAmount(threshold, token.decimals()); err != nil {
+			return err
+		}
+		admins, err := s.getAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if required > len(admins) {
+			return fmt.Errorf("%d approvals required but only %d admins are registered", required, len(admins))
+		}
+	}
+
+	args := []string{string(limit), strconv.Itoa(required)}
+	done, err := s.proposeAdminAction(ctx, adminActionMintPolicy, tokenID, args)
+	if err != nil || !done {
+		return err
+	}
+	token.MintApprovalThreshold = limit
+	token.MintApprovalsRequired = required
+	return s.putToken(ctx, token)
+}
+
+// recordMintApproval adds the calling admin's approval to r and reports whether approvals from
+// admins still in the registry now reach required
+func (s *SmartContract) recordMintApproval(ctx contractapi.TransactionContextInterface, r *MintRequest, required int) (bool, error) {
+	approvals, err := s.addApproval(ctx, r.Approvals, "mint request")
+	if err != nil {
+		return false, err
+	}
+	r.Approvals = approvals
+	r.ApprovalsRequired = required
+
+	count, err := s.countApprovals(ctx, r.Approvals)
+	if err != nil {
+		return false, err
+	}
+	return count >= required, nil
+}
+
+// addApproval appends the calling admin's approval of a request of the given kind
+func (s *SmartContract) addApproval(ctx contractapi.TransactionContextInterface, approvals []MintApproval, kind string) ([]MintApproval, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, err
+	}
+	msp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range approvals {
+		if a.ClientID == clientID {
+			return nil, fmt.Errorf("admin already approved this %s", kind)
+		}
+	}
+	approvedAt, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(approvals, MintApproval{ClientID: clientID, MSPID: msp, ApprovedAt: approvedAt}), nil
+}
+
+// countApprovals counts the approvals given by admins still in the registry
+func (s *SmartContract) countApprovals(ctx contractapi.TransactionContextInterface, approvals []MintApproval) (int, error) {
+	admins, err := s.getAdmins(ctx)
+	if err != nil {
+		return 0, err
+	}
+	registered := make(map[string]string)
+	for _, a := range admins {
+		registered[a.ClientID] = a.MSPID
+	}
+	count := 0
+	for _, a := range approvals {
+		if registered[a.ClientID] == a.MSPID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetMintApprovals shows which admins approved a mint request and how many its token requires;
+// open to admins and the requester
+func (s *SmartContract) GetMintApprovals(ctx contractapi.TransactionContextInterface, requestID string) (*MintApprovals, error) {
+	requester := ""
+	if s.VerifyAdmin(ctx) != nil {
+		if err := s.requirePermission(ctx, permParticipate); err != nil {
+			return nil, err
+		}
+		p, err := s.callerParticipant(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		requester = p.NetworkAddress
+	}
+	r, err := s.getMintRequest(ctx, mintRequestKeyPrefix, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if requester != "" && r.RequestedBy != requester {
+		return nil, fmt.Errorf("caller is not the requester")
+	}
+
+	res := &MintApprovals{
+		RequestID: r.RequestID,
+		TokenID:   r.TokenID,
+		Amount:    r.Amount,
+		Status:    r.Status,
+		Required:  r.ApprovalsRequired,
+		Approvals: r.Approvals,
+	}
+	if res.Approvals == nil {
+		res.Approvals = []MintApproval{}
+	}
+	// Pending requests also follow a policy tightened after they were filed
+	if r.Status == mintRequestPending {
+		token, err := s.getToken(ctx, r.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		res.Required = token.mintApprovalsRequired(r)
+	}
+	if res.Required == 0 {
+		res.Required = 1
+	}
+	return res, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// migrateNetworkAddresses runs MigrateNetworkAddresses batch by batch over the whole ledger
+func migrateNetworkAddresses(h *TestHelper) (map[string]string, error) {
+	moved := make(map[string]string)
+	for startKey := ""; ; {
+		res, err := h.Contract.MigrateNetworkAddresses(h.Ctx, startKey, 3)
+		if err != nil {
+			return nil, err
+		}
+		for oldAddr, newAddr := range res.Moved {
+			moved[oldAddr] = newAddr
+		}
+		if res.Bookmark == "" {
+			return moved, nil
+		}
+		startKey = res.Bookmark
+	}
+}
+
+func TestMigrateNetworkAddressesRekeysActivity(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, aliceToken := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	h.SetAsAdmin()
+	oldAddr := legacyNetworkAddress("Bob")
+	legacy := Participant{Name: "Bob", NetworkAddress: oldAddr, ClientID: "bob-id", PasswordHash: "bobhash", Country: "UK"}
+	pb, _ := json.Marshal(legacy)
+	h.Stub.State[oldAddr] = pb
+	h.Stub.State[participantIDKey("bob-id")] = []byte(oldAddr)
+	assert.NoError(t, h.VerifyParticipant(oldAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, "Bob", oldAddr, "bobhash", "UK", ""))
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, oldAddr))
+	bob, err := h.GetParticipant(oldAddr)
+	assert.NoError(t, err)
+	bobToken := bob.TokenIDs[0]
+
+	// Bob mints, pays out a customer redemption, sends a transfer and pauses his token
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	h.NewTx()
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, "", bobToken, "bobhash", "50")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	setupCustomer(t, h, "bob-id", "carol", bobToken)
+	h.NewTx()
+	custMintID, err := h.Contract.CustomerRequestMint(h.Ctx, "carol", bobToken, "20")
+	assert.NoError(t, err)
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveCustomerMint(h.Ctx, custMintID, ""))
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	h.NewTx()
+	redemptionID, err := h.Contract.RequestRedemption(h.Ctx, "carol", bobToken, "custpass", "5")
+	assert.NoError(t, err)
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ConfirmRedemption(h.Ctx, redemptionID, "PAY-1", ""))
+
+	transferID, err := h.Contract.CreateTransferRequest(h.Ctx, "", "", bobToken, "", aliceToken, "10")
+	assert.NoError(t, err)
+	h.NewTx()
+	assert.NoError(t, h.Contract.PauseToken(h.Ctx, bobToken, "audit"))
+
+	h.SetAsAdmin()
+	moved, err := migrateNetworkAddresses(h)
+	assert.NoError(t, err)
+	newAddr := moved[oldAddr]
+	assert.NotEmpty(t, newAddr)
+
+	var transfer TransferRequest
+	assert.NoError(t, json.Unmarshal(h.Stub.State[transferID], &transfer))
+	assert.Equal(t, newAddr, transfer.SenderTransferID)
+	var redemption Redemption
+	assert.NoError(t, json.Unmarshal(h.Stub.State[redemptionID], &redemption))
+	assert.Equal(t, newAddr, redemption.ProcessedBy)
+	token, err := h.GetToken(bobToken)
+	assert.NoError(t, err)
+	assert.Equal(t, newAddr, token.Owner)
+	assert.Equal(t, newAddr, token.PausedBy)
+	unissued := token.Minted
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	actions, err := h.Contract.GetTokenPauseHistory(h.Ctx, bobToken)
+	assert.NoError(t, err)
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, newAddr, actions[0].By)
+	}
+
+	// The moved sender completes its transfer from its token
+	h.NewTx()
+	assert.NoError(t, h.Contract.ResumeToken(h.Ctx, bobToken, "audit done"))
+	assert.NoError(t, h.Contract.ApproveTransferByOwner(h.Ctx, transferID, ""))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveTransferByReceiver(h.Ctx, transferID, ""))
+	token, err = h.GetToken(bobToken)
+	assert.NoError(t, err)
+	want, err := unissued.sub(Amount("10"))
+	assert.NoError(t, err)
+	assert.Equal(t, want, token.Minted)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Ownership transfer statuses
+const (
+	ownershipProposed = "PROPOSED"
+	ownershipAccepted = "ACCEPTED"
+	ownershipApproved = "APPROVED"
+	ownershipRejected = "REJECTED"
+
+	ownershipTransferKeyPrefix = "ownershiptransfer_"
+)
+
+// TokenOwnershipTransfer hands a token from one participant to another once the receiving
+// participant accepts and an admin approves
+type TokenOwnershipTransfer struct {
+	TransferID string `json:"transfer_id"`
+	TokenID    string `json:"token_id"`
+	FromOwner  string `json:"from_owner"`
+	ToOwner    string `json:"to_owner"`
+	Status     string `json:"status"` // PROPOSED, ACCEPTED, APPROVED, REJECTED
+	Reason     string `json:"reason,omitempty"`
+	ApprovedBy string `json:"approved_by,omitempty"`
+}
+
+func (s *SmartContract) getOwnershipTransfer(ctx contractapi.TransactionContextInterface, transferID string) (*TokenOwnershipTransfer, error) {
+	b, err := ctx.GetStub().GetState(transferID)
+	if err != nil || b == nil || !strings.HasPrefix(transferID, ownershipTransferKeyPrefix) {
+		return nil, fmt.Errorf("ownership transfer not found")
+	}
+	var ot TokenOwnershipTransfer
+	if err := json.Unmarshal(b, &ot); err != nil {
+		return nil, err
+	}
+	return &ot, nil
+}
+
+func (s *SmartContract) putOwnershipTransfer(ctx contractapi.TransactionContextInterface, ot *TokenOwnershipTransfer) error {
+	b, err := json.Marshal(ot)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(ot.TransferID, b)
+}
+
+// listOwnershipTransfers returns the transfers of tokenID, or of every token if tokenID is
+// empty, whose status is one of statuses
+func (s *SmartContract) listOwnershipTransfers(ctx contractapi.TransactionContextInterface, tokenID string, statuses ...string) ([]TokenOwnershipTransfer, error) {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var list []TokenOwnershipTransfer
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(kv.Key, ownershipTransferKeyPrefix) {
+			continue
+		}
+		var ot TokenOwnershipTransfer
+		if json.Unmarshal(kv.Value, &ot) != nil || (tokenID != "" && ot.TokenID != tokenID) {
+			continue
+		}
+		for _, st := range statuses {
+			if ot.Status == st {
+				list = append(list, ot)
+				break
+			}
+		}
+	}
+	return list, nil
+}
+
+// ProposeTokenOwnershipTransfer lets a token owner offer its token to another participant;
+// returns the transfer ID
+func (s *SmartContract) ProposeTokenOwnershipTransfer(ctx contractapi.TransactionContextInterface, tokenID, newOwnerAddress string) (string, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return "", err
+	}
+	owner, err := s.callerParticipant(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	if err := checkNotFrozen(owner); err != nil {
+		return "", err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	if token.Owner != owner.NetworkAddress {
+		return "", fmt.Errorf("caller is not token owner")
+	}
+	if newOwnerAddress == owner.NetworkAddress {
+		return "", fmt.Errorf("token already owned by participant")
+	}
+	if _, err := s.getParticipant(ctx, newOwnerAddress); err != nil {
+		return "", err
+	}
+	open, err := s.listOwnershipTransfers(ctx, tokenID, ownershipProposed, ownershipAccepted)
+	if err != nil {
+		return "", err
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("token already has an open ownership transfer: %s", open[0].TransferID)
+	}
+
+	ot := &TokenOwnershipTransfer{
+		TransferID: fmt.Sprintf("%s%s_%s", ownershipTransferKeyPrefix, tokenID, ctx.GetStub().GetTxID()),
+		TokenID:    tokenID,
+		FromOwner:  owner.NetworkAddress,
+		ToOwner:    newOwnerAddress,
+		Status:     ownershipProposed,
+	}
+	return ot.TransferID, s.putOwnershipTransfer(ctx, ot)
+}
+
+// AcceptTokenOwnershipTransfer lets the receiving participant accept a proposed transfer
+func (s *SmartContract) AcceptTokenOwnershipTransfer(ctx contractapi.TransactionContextInterface, transferID string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	ot, err := s.getOwnershipTransfer(ctx, transferID)
+	if err != nil {
+		return err
+	}
+	if ot.Status != ownershipProposed {
+		return fmt.Errorf("ownership transfer is %s", ot.Status)
+	}
+	p, err := s.callerParticipant(ctx, ot.ToOwner)
+	if err != nil {
+		return err
+	}
+	if err := checkNewTokenOwner(p); err != nil {
+		return err
+	}
+
+	ot.Status = ownershipAccepted
+	return s.putOwnershipTransfer(ctx, ot)
+}
+
+// checkNewTokenOwner refuses a participant that cannot take over a token
+func checkNewTokenOwner(p *Participant) error {
+	if err := checkVerified(p); err != nil {
+		return err
+	}
+	return checkNotFrozen(p)
+}
+
+// GetPendingTokenOwnershipTransfers lists proposed and accepted transfers (admin)
+func (s *SmartContract) GetPendingTokenOwnershipTransfers(ctx contractapi.TransactionContextInterface) ([]TokenOwnershipTransfer, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.listOwnershipTransfers(ctx, "", ownershipProposed, ownershipAccepted)
+}
+
+// ApproveTokenOwnershipTransfer moves an accepted transfer's token, with its minted coins,
+// to the new owner. Customer records are keyed by token and follow it unchanged.
+func (s *SmartContract) ApproveTokenOwnershipTransfer(ctx contractapi.TransactionContextInterface, transferID string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	ot, err := s.getOwnershipTransfer(ctx, transferID)
+	if err != nil {
+		return err
+	}
+	if ot.Status != ownershipAccepted {
+		return fmt.Errorf("ownership transfer not accepted by new owner")
+	}
+	token, err := s.getToken(ctx, ot.TokenID)
+	if err != nil {
+		return err
+	}
+	if token.Owner != ot.FromOwner {
+		return fmt.Errorf("token no longer owned by proposer")
+	}
+	from, err := s.getParticipant(ctx, ot.FromOwner)
+	if err != nil {
+		return err
+	}
+	if err := checkNotFrozen(from); err != nil {
+		return err
+	}
+	to, err := s.getParticipant(ctx, ot.ToOwner)
+	if err != nil {
+		return err
+	}
+	if err := checkNewTokenOwner(to); err != nil {
+		return err
+	}
+	adminID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+
+	from.removeOwnedToken(ot.TokenID)
+	if err := s.putParticipant(ctx, from, false); err != nil {
+		return err
+	}
+	to.addOwnedToken(ot.TokenID)
+	if err := s.putParticipant(ctx, to, false); err != nil {
+		return err
+	}
+	token.Owner = ot.ToOwner
+	if err := s.putToken(ctx, token); err != nil {
+		return err
+	}
+
+	// The previous owner's pending mint requests would no longer pass ApproveMintRequest
+	if err := s.rejectPendingMintRequests(ctx, ot.TokenID, ot.FromOwner, "token ownership transferred"); err != nil {
+		return err
+	}
+	r, err := s.approvedTokenRequest(ctx, ot.FromOwner, ot.TokenID)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		r.Status = "TRANSFERRED"
+		if err := s.putTokenRequest(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	ot.Status = ownershipApproved
+	ot.ApprovedBy = adminID
+	return s.putOwnershipTransfer(ctx, ot)
+}
+
+// RejectTokenOwnershipTransfer closes an open transfer, leaving the token with its owner;
+// the admin or either party may reject
+func (s *SmartContract) RejectTokenOwnershipTransfer(ctx contractapi.TransactionContextInterface, transferID, reason string) error {
+	ot, err := s.getOwnershipTransfer(ctx, transferID)
+	if err != nil {
+		return err
+	}
+	if ot.Status != ownershipProposed && ot.Status != ownershipAccepted {
+		return fmt.Errorf("ownership transfer is %s", ot.Status)
+	}
+	if s.VerifyAdmin(ctx) != nil {
+		if err := s.requirePermission(ctx, permParticipate); err != nil {
+			return err
+		}
+		p, err := s.callerParticipant(ctx, "")
+		if err != nil {
+			return err
+		}
+		if p.NetworkAddress != ot.FromOwner && p.NetworkAddress != ot.ToOwner {
+			return fmt.Errorf("caller is not a party to the ownership transfer")
+		}
+	}
+
+	ot.Status = ownershipRejected
+	ot.Reason = reason
+	return s.putOwnershipTransfer(ctx, ot)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspendedOwnerCannotApproveCustomers(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	alice, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, alice, tokenID, "pass123", "50")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+
+	setupCustomer(t, h, "alice-id", "carol", tokenID)
+	h.NewTx()
+	custMintID, err := h.Contract.CustomerRequestMint(h.Ctx, "carol", tokenID, "10")
+	assert.NoError(t, err)
+	h.SetIdentity("dave-id", "Org1MSP", "customer")
+	assert.NoError(t, h.Contract.RegisterCustomer(h.Ctx, "dave", "Dave", "custpass", tokenID))
+
+	// A suspended owner approves neither registrations nor mints until verified again
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SuspendParticipant(h.Ctx, alice, "expired documents"))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	err = h.Contract.ApproveCustomerRegistration(h.Ctx, "custreq_dave_"+tokenID, "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "status SUSPENDED")
+	}
+	err = h.Contract.ApproveCustomerMint(h.Ctx, custMintID, "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "status SUSPENDED")
+	}
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.VerifyParticipant(h.Ctx, alice))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveCustomerRegistration(h.Ctx, "custreq_dave_"+tokenID, ""))
+	assert.NoError(t, h.Contract.ApproveCustomerMint(h.Ctx, custMintID, ""))
+	cust, err := h.GetCustomer("carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("10"), cust.Balance)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	pauseActionPause  = "PAUSE"
+	pauseActionResume = "RESUME"
+
+	pauseActionKeyPrefix = "pauseaction_"
+)
+
+// TokenPauseAction records a PauseToken or ResumeToken call
+type TokenPauseAction struct {
+	ActionID string `json:"action_id"`
+	TokenID  string `json:"token_id"`
+	Action   string `json:"action"` // PAUSE or RESUME
+	Reason   string `json:"reason"`
+	By       string `json:"by"` // owner network address or admin client ID
+	ByAdmin  bool   `json:"by_admin"`
+}
+
+// checkNotPaused refuses customer activity on a paused token
+func checkNotPaused(t *Token) error {
+	if t.Paused {
+		return fmt.Errorf("token is paused: %s", t.PauseReason)
+	}
+	return nil
+}
+
+// requireTokenNotPaused loads tokenID and refuses customer activity while it is paused
+func (s *SmartContract) requireTokenNotPaused(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	return checkNotPaused(token)
+}
+
+// pauseActor resolves who may pause or resume tokenID: an admin, or its unfrozen owner
+func (s *SmartContract) pauseActor(ctx contractapi.TransactionContextInterface, tokenID string) (*Token, string, bool, error) {
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if s.VerifyAdmin(ctx) == nil {
+		adminID, err := ctx.GetClientIdentity().GetID()
+		if err != nil {
+			return nil, "", false, err
+		}
+		return token, adminID, true, nil
+	}
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, "", false, err
+	}
+	owner, err := s.callerParticipant(ctx, "")
+	if err != nil {
+		return nil, "", false, err
+	}
+	if err := checkNotFrozen(owner); err != nil {
+		return nil, "", false, err
+	}
+	if token.Owner != owner.NetworkAddress {
+		return nil, "", false, fmt.Errorf("caller is not token owner")
+	}
+	return token, owner.NetworkAddress, false, nil
+}
+
+func (s *SmartContract) recordPauseAction(ctx contractapi.TransactionContextInterface, tokenID, action, reason, by string, byAdmin bool) error {
+	a := TokenPauseAction{
+		ActionID: pauseActionKeyPrefix + tokenID + "_" + ctx.GetStub().GetTxID(),
+		TokenID:  tokenID,
+		Action:   action,
+		Reason:   reason,
+		By:       by,
+		ByAdmin:  byAdmin,
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(a.ActionID, b)
+}
+
+// PauseToken halts customer registration, customer minting and transfers on a token, e.g.
+// while fraud is investigated; queries keep working. Open to the token owner and admins.
+func (s *SmartContract) PauseToken(ctx contractapi.TransactionContextInterface, tokenID, reason string) error {
+	token, by, byAdmin, err := s.pauseActor(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("pause reason is required")
+	}
+	if token.Paused {
+		return fmt.Errorf("token already paused")
+	}
+
+	token.Paused = true
+	token.PauseReason = reason
+	token.PausedBy = by
+	token.PausedByAdmin = byAdmin
+	if err := s.putToken(ctx, token); err != nil {
+		return err
+	}
+	return s.recordPauseAction(ctx, tokenID, pauseActionPause, reason, by, byAdmin)
+}
+
+// ResumeToken lifts a pause; a pause set by an admin can only be lifted by an admin
+func (s *SmartContract) ResumeToken(ctx contractapi.TransactionContextInterface, tokenID, reason string) error {
+	token, by, byAdmin, err := s.pauseActor(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("resume reason is required")
+	}
+	if !token.Paused {
+		return fmt.Errorf("token not paused")
+	}
+	if token.PausedByAdmin && !byAdmin {
+		return fmt.Errorf("token was paused by an admin")
+	}
+
+	clearPause(token)
+	if err := s.putToken(ctx, token); err != nil {
+		return err
+	}
+	return s.recordPauseAction(ctx, tokenID, pauseActionResume, reason, by, byAdmin)
+}
+
+func clearPause(t *Token) {
+	t.Paused = false
+	t.PauseReason = ""
+	t.PausedBy = ""
+	t.PausedByAdmin = false
+}
+
+// GetTokenPauseHistory lists the pause and resume actions on a token; open to the token owner and admins
+func (s *SmartContract) GetTokenPauseHistory(ctx contractapi.TransactionContextInterface, tokenID string) ([]TokenPauseAction, error) {
+	if _, _, _, err := s.pauseActor(ctx, tokenID); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var list []TokenPauseAction
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, pauseActionKeyPrefix+tokenID+"_") {
+			var a TokenPauseAction
+			if err := json.Unmarshal(kv.Value, &a); err == nil && a.TokenID == tokenID {
+				list = append(list, a)
+			}
+		}
+	}
+	return list, nil
+}
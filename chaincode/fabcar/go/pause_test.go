@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseTokenBlocksCustomerActivity(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	alice, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	_, bobToken := setupTokenOwner(t, h, "bob-id", "Bob")
+	fundToken(t, h, "bob-id", bobToken, "100")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, alice, tokenID, "pass123", "50")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+
+	// Work in flight when the token is paused
+	setupCustomer(t, h, "alice-id", "carol", tokenID)
+	h.NewTx()
+	custMintID, err := h.Contract.CustomerRequestMint(h.Ctx, "carol", tokenID, "10")
+	assert.NoError(t, err)
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	awaitingSender, err := h.Contract.CreateTransferRequest(h.Ctx, "", "", bobToken, "", tokenID, "5")
+	assert.NoError(t, err)
+	awaitingReceiver, err := h.Contract.CreateTransferRequest(h.Ctx, "", "", bobToken, "", tokenID, "5")
+	assert.NoError(t, err)
+	assert.NoError(t, h.Contract.ApproveTransferByOwner(h.Ctx, awaitingReceiver, ""))
+
+	// Only the token owner and admins pause, with a reason
+	assert.Error(t, h.Contract.PauseToken(h.Ctx, tokenID, "fraud"))
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	assert.Error(t, h.Contract.PauseToken(h.Ctx, tokenID, "fraud"))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.PauseToken(h.Ctx, tokenID, ""))
+	assert.NoError(t, h.Contract.PauseToken(h.Ctx, tokenID, "fraud"))
+	assert.Error(t, h.Contract.PauseToken(h.Ctx, tokenID, "fraud"))
+
+	assertPaused := func(err error) {
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "token is paused: fraud")
+		}
+	}
+	h.SetIdentity("dave-id", "Org1MSP", "customer")
+	assertPaused(h.Contract.RegisterCustomer(h.Ctx, "dave", "Dave", "custpass", tokenID))
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	h.NewTx()
+	_, err = h.Contract.CustomerRequestMint(h.Ctx, "carol", tokenID, "10")
+	assertPaused(err)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assertPaused(h.Contract.ApproveCustomerMint(h.Ctx, custMintID, ""))
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.CreateTransferRequest(h.Ctx, "", "", bobToken, "", tokenID, "5")
+	assertPaused(err)
+	assertPaused(h.Contract.ApproveTransferByOwner(h.Ctx, awaitingSender, ""))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assertPaused(h.Contract.ApproveTransferByReceiver(h.Ctx, awaitingReceiver, ""))
+
+	// Queries keep working
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	wallet, err := h.Contract.ViewCustomerWallet(h.Ctx, "carol", tokenID, "custpass")
+	assert.NoError(t, err)
+	assert.Equal(t, zeroAmount, wallet["balance"])
+	history, err := h.Contract.GetTokenTransferHistory(h.Ctx, tokenID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	// Resuming reopens the blocked paths
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.ResumeToken(h.Ctx, tokenID, "cleared"))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.ResumeToken(h.Ctx, tokenID, ""))
+	assert.NoError(t, h.Contract.ResumeToken(h.Ctx, tokenID, "cleared"))
+	assert.NoError(t, h.Contract.ApproveCustomerMint(h.Ctx, custMintID, ""))
+	assert.NoError(t, h.Contract.ApproveTransferByReceiver(h.Ctx, awaitingReceiver, ""))
+	cust, err := h.GetCustomer("carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("10"), cust.Balance)
+}
+
+func TestAdminPauseNeedsAdminResume(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.PauseToken(h.Ctx, tokenID, "regulator order"))
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.True(t, token.Paused)
+	assert.True(t, token.PausedByAdmin)
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	err = h.Contract.ResumeToken(h.Ctx, tokenID, "resolved")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "paused by an admin")
+	h.SetAsAdmin()
+	h.NewTx()
+	assert.NoError(t, h.Contract.ResumeToken(h.Ctx, tokenID, "resolved"))
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	actions, err := h.Contract.GetTokenPauseHistory(h.Ctx, tokenID)
+	assert.NoError(t, err)
+	if assert.Len(t, actions, 2) {
+		assert.ElementsMatch(t, []string{pauseActionPause, pauseActionResume}, []string{actions[0].Action, actions[1].Action})
+	}
+}
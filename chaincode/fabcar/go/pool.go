@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// initialTokens is the number of tokens InitLedger creates on an empty ledger
+	initialTokens = 25
+	// maxTokensPerCall bounds the write set of a single CreateTokens call
+	maxTokensPerCall = 100
+
+	tokenIDPrefix = "token_"
+	// tokenSeqKey holds the highest N used for a generated token_N
+	tokenSeqKey = "config_token_seq"
+	// poolObjectType indexes tokens that are available for assignment. Composite keys stay
+	// out of the plain range scans used by the listing transactions.
+	poolObjectType = "pool"
+)
+
+// ensureTokenPool returns the token sequence. Ledgers created before the pool existed get
+// their sequence and pool index rebuilt from the existing token_N records.
+func (s *SmartContract) ensureTokenPool(ctx contractapi.TransactionContextInterface) (int, error) {
+	b, err := ctx.GetStub().GetState(tokenSeqKey)
+	if err != nil {
+		return 0, err
+	}
+	if b != nil {
+		return strconv.Atoi(string(b))
+	}
+
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	seq := 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(kv.Key, tokenIDPrefix) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(kv.Key, tokenIDPrefix)); err == nil && n > seq {
+			seq = n
+		}
+		var t Token
+		if json.Unmarshal(kv.Value, &t) != nil {
+			continue
+		}
+		if t.Available {
+			if err := s.addTokenToPool(ctx, kv.Key); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return seq, s.putTokenSeq(ctx, seq)
+}
+
+func (s *SmartContract) putTokenSeq(ctx contractapi.TransactionContextInterface, seq int) error {
+	return ctx.GetStub().PutState(tokenSeqKey, []byte(strconv.Itoa(seq)))
+}
+
+func (s *SmartContract) addTokenToPool(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(poolObjectType, []string{tokenID})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, []byte(tokenID))
+}
+
+func (s *SmartContract) removeTokenFromPool(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(poolObjectType, []string{tokenID})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// createToken stores a new available token and adds it to the pool, or hands it to the
+// first waitlisted participant
+func (s *SmartContract) createToken(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	existing, err := ctx.GetStub().GetState(tokenID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("token %s already exists", tokenID)
+	}
+	token := Token{TokenID: tokenID, Owner: "", Available: true, Minted: zeroAmount}
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(tokenID, b); err != nil {
+		return err
+	}
+	if err := s.addTokenToPool(ctx, tokenID); err != nil {
+		return err
+	}
+	return s.assignFromWaitlist(ctx, tokenID)
+}
+
+// createTokens adds count tokens named token_N after the current sequence, skipping IDs already taken
+func (s *SmartContract) createTokens(ctx contractapi.TransactionContextInterface, seq, count int) ([]string, error) {
+	var created []string
+	for len(created) < count {
+		seq++
+		tid := fmt.Sprintf("%s%d", tokenIDPrefix, seq)
+		existing, err := ctx.GetStub().GetState(tid)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			continue
+		}
+		if err := s.createToken(ctx, tid); err != nil {
+			return nil, err
+		}
+		created = append(created, tid)
+	}
+	return created, s.putTokenSeq(ctx, seq)
+}
+
+// CreateTokens lets an admin grow the token pool by count tokens; returns the new token IDs
+func (s *SmartContract) CreateTokens(ctx contractapi.TransactionContextInterface, count int) ([]string, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if count < 1 || count > maxTokensPerCall {
+		return nil, fmt.Errorf("count must be between 1 and %d", maxTokensPerCall)
+	}
+	seq, err := s.ensureTokenPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.createTokens(ctx, seq, count)
+}
+
+// CreateToken lets an admin add a single token with a chosen ID, which must start with token_
+func (s *SmartContract) CreateToken(ctx contractapi.TransactionContextInterface, tokenID string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(tokenID, tokenIDPrefix) || tokenID == tokenIDPrefix {
+		return fmt.Errorf("token id must start with %s", tokenIDPrefix)
+	}
+	if _, err := s.ensureTokenPool(ctx); err != nil {
+		return err
+	}
+	return s.createToken(ctx, tokenID)
+}
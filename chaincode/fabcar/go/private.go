@@ -0,0 +1,267 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// piiCollection is the private data collection holding participant and customer PII,
+// see collections_config.json. Only Org1 peers are members, so the chaincode is deployed with
+// an Org1-only endorsement policy (test-network/deploy-fabcar.sh)
+const piiCollection = "collectionPII"
+
+// ParticipantPII is the part of a participant kept in the PII collection
+type ParticipantPII struct {
+	Name         string              `json:"name"`
+	Country      string              `json:"country"`
+	PasswordHash string              `json:"password_hash"`
+	Credential   *PasswordCredential `json:"credential"`
+}
+
+// CustomerPII is the part of a customer, or of its registration request, kept in the PII collection
+type CustomerPII struct {
+	Name         string              `json:"name"`
+	PasswordHash string              `json:"password_hash"`
+	Credential   *PasswordCredential `json:"credential"`
+}
+
+// putPrivateDetails writes v to the PII collection and returns the hash stored on the public record.
+// The private record carries a salted credential, so the hash cannot be brute-forced from guessed PII.
+func putPrivateDetails(ctx contractapi.TransactionContextInterface, key string, v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutPrivateData(piiCollection, key, b); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// getPrivateDetails reads key from the PII collection into v after checking it against the public hash
+func getPrivateDetails(ctx contractapi.TransactionContextInterface, key, expectedHash string, v interface{}) error {
+	b, err := ctx.GetStub().GetPrivateData(piiCollection, key)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return fmt.Errorf("private data for %s not available on this peer", key)
+	}
+	hash := sha256.Sum256(b)
+	if hex.EncodeToString(hash[:]) != expectedHash {
+		return fmt.Errorf("private data for %s does not match its public hash", key)
+	}
+	return json.Unmarshal(b, v)
+}
+
+// getParticipant reads the public participant record; PII is only present on records
+// written before the PII collection existed
+func (s *SmartContract) getParticipant(ctx contractapi.TransactionContextInterface, networkAddress string) (*Participant, error) {
+	pb, err := ctx.GetStub().GetState(networkAddress)
+	if err != nil || pb == nil {
+		return nil, fmt.Errorf("participant not found")
+	}
+	var p Participant
+	if err := json.Unmarshal(pb, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// loadParticipantPII joins the private details into p
+func (s *SmartContract) loadParticipantPII(ctx contractapi.TransactionContextInterface, p *Participant) error {
+	if p.PrivateDataHash == "" {
+		return nil // legacy record, PII is still inline
+	}
+	var pii ParticipantPII
+	if err := getPrivateDetails(ctx, p.NetworkAddress, p.PrivateDataHash, &pii); err != nil {
+		return err
+	}
+	p.Name, p.Country, p.PasswordHash, p.Credential = pii.Name, pii.Country, pii.PasswordHash, pii.Credential
+	return nil
+}
+
+// putParticipant writes the public record without PII. The private details are rewritten when
+// withPII is set or when the record has none yet, which moves legacy inline PII out of world state.
+func (s *SmartContract) putParticipant(ctx contractapi.TransactionContextInterface, p *Participant, withPII bool) error {
+	if withPII || p.PrivateDataHash == "" {
+		hash, err := putPrivateDetails(ctx, p.NetworkAddress, ParticipantPII{Name: p.Name, Country: p.Country, PasswordHash: p.PasswordHash, Credential: p.Credential})
+		if err != nil {
+			return err
+		}
+		p.PrivateDataHash = hash
+	}
+
+	public := *p
+	public.Name, public.Country, public.PasswordHash, public.Credential = "", "", "", nil
+	pb, err := json.Marshal(public)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(p.NetworkAddress, pb)
+}
+
+// getCustomer reads the public customer record stored under customerKey
+func (s *SmartContract) getCustomer(ctx contractapi.TransactionContextInterface, customerKey string) (*Customer, error) {
+	custBytes, err := ctx.GetStub().GetState(customerKey)
+	if err != nil || custBytes == nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+	var cust Customer
+	if err := json.Unmarshal(custBytes, &cust); err != nil {
+		return nil, err
+	}
+	return &cust, nil
+}
+
+// loadCustomerPII joins the private details into cust
+func (s *SmartContract) loadCustomerPII(ctx contractapi.TransactionContextInterface, customerKey string, cust *Customer) error {
+	if cust.PrivateDataHash == "" {
+		return nil
+	}
+	var pii CustomerPII
+	if err := getPrivateDetails(ctx, customerKey, cust.PrivateDataHash, &pii); err != nil {
+		return err
+	}
+	cust.Name, cust.PasswordHash, cust.Credential = pii.Name, pii.PasswordHash, pii.Credential
+	return nil
+}
+
+// putCustomer writes the public customer record without PII, see putParticipant
+func (s *SmartContract) putCustomer(ctx contractapi.TransactionContextInterface, customerKey string, cust *Customer, withPII bool) error {
+	if withPII || cust.PrivateDataHash == "" {
+		hash, err := putPrivateDetails(ctx, customerKey, CustomerPII{Name: cust.Name, PasswordHash: cust.PasswordHash, Credential: cust.Credential})
+		if err != nil {
+			return err
+		}
+		cust.PrivateDataHash = hash
+	}
+
+	public := *cust
+	public.Name, public.PasswordHash, public.Credential = "", "", nil
+	custBytes, err := json.Marshal(public)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(customerKey, custBytes)
+}
+
+// getCustomerRequest reads a customer registration request joined with its private details
+func (s *SmartContract) getCustomerRequest(ctx contractapi.TransactionContextInterface, requestID string) (*RegisterCustomerRequest, error) {
+	reqBytes, err := ctx.GetStub().GetState(requestID)
+	if err != nil || reqBytes == nil {
+		return nil, fmt.Errorf("customer registration request not found")
+	}
+	var req RegisterCustomerRequest
+	if err := json.Unmarshal(reqBytes, &req); err != nil {
+		return nil, err
+	}
+	if err := s.loadCustomerRequestPII(ctx, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (s *SmartContract) loadCustomerRequestPII(ctx contractapi.TransactionContextInterface, req *RegisterCustomerRequest) error {
+	if req.PrivateDataHash == "" {
+		return nil
+	}
+	var pii CustomerPII
+	if err := getPrivateDetails(ctx, req.RequestID, req.PrivateDataHash, &pii); err != nil {
+		return err
+	}
+	req.Name, req.PasswordHash, req.Credential = pii.Name, pii.PasswordHash, pii.Credential
+	return nil
+}
+
+// putCustomerRequest writes a customer registration request without PII, see putParticipant
+func (s *SmartContract) putCustomerRequest(ctx contractapi.TransactionContextInterface, req *RegisterCustomerRequest, withPII bool) error {
+	if withPII || req.PrivateDataHash == "" {
+		hash, err := putPrivateDetails(ctx, req.RequestID, CustomerPII{Name: req.Name, PasswordHash: req.PasswordHash, Credential: req.Credential})
+		if err != nil {
+			return err
+		}
+		req.PrivateDataHash = hash
+	}
+
+	public := *req
+	public.Name, public.PasswordHash, public.Credential = "", "", nil
+	reqBytes, err := json.Marshal(public)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(req.RequestID, reqBytes)
+}
+
+// GetParticipantDetails returns a participant joined with its private details,
+// for the participant itself or an admin
+func (s *SmartContract) GetParticipantDetails(ctx contractapi.TransactionContextInterface, networkAddress string) (*Participant, error) {
+	p, err := s.callerParticipant(ctx, networkAddress)
+	if err == nil {
+		err = s.requirePermission(ctx, permParticipate)
+	} else if adminErr := s.VerifyAdmin(ctx); adminErr == nil {
+		p, err = s.getParticipant(ctx, networkAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadParticipantPII(ctx, p); err != nil {
+		return nil, err
+	}
+	p.clearSecrets()
+	return p, nil
+}
+
+// MigratePrivateData moves PII still stored inline in public participant, customer and
+// customer request records into the PII collection. Returns the number of migrated records.
+func (s *SmartContract) MigratePrivateData(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return 0, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	migrated := 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case strings.HasPrefix(kv.Key, "customer_"):
+			var cust Customer
+			if json.Unmarshal(kv.Value, &cust) != nil || cust.PrivateDataHash != "" {
+				continue
+			}
+			err = s.putCustomer(ctx, kv.Key, &cust, true)
+		case strings.HasPrefix(kv.Key, "custreq_"):
+			var req RegisterCustomerRequest
+			if json.Unmarshal(kv.Value, &req) != nil || req.PrivateDataHash != "" {
+				continue
+			}
+			err = s.putCustomerRequest(ctx, &req, true)
+		default:
+			var p Participant
+			if json.Unmarshal(kv.Value, &p) != nil || p.NetworkAddress != kv.Key || p.PrivateDataHash != "" {
+				continue
+			}
+			err = s.putParticipant(ctx, &p, true)
+		}
+		if err != nil {
+			return 0, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnedRecordsCarryNoSecrets(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	pb, _ := json.Marshal(Participant{Name: "Bob", NetworkAddress: "addrbob", PasswordHash: "bobhash", Country: "UK"})
+	h.Stub.State["addrbob"] = pb
+
+	p, err := h.Contract.GetParticipantDetails(h.Ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", p.Name)
+	assert.Nil(t, p.Credential)
+	assert.Empty(t, p.PasswordHash)
+
+	h.SetAsAdmin()
+	pending, err := h.Contract.GetPendingRegistrations(h.Ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, "Bob", pending[0].Name)
+		assert.Empty(t, pending[0].PasswordHash)
+	}
+
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	assert.NoError(t, h.Contract.RegisterCustomer(h.Ctx, "carol", "Carol", "custpass", tokenID))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	requests, err := h.Contract.ViewPendingCustomerRegistrations(h.Ctx, tokenID, "")
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "Carol", requests[0].Name)
+		assert.Nil(t, requests[0].Credential)
+		assert.Empty(t, requests[0].PasswordHash)
+	}
+
+	// The secrets are only hidden from callers, the approved customer still authenticates
+	assert.NoError(t, h.Contract.ApproveCustomerRegistration(h.Ctx, "custreq_carol_"+tokenID, ""))
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	_, err = h.Contract.ViewCustomerWallet(h.Ctx, "carol", tokenID, "custpass")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Redemption statuses
+const (
+	redemptionPending   = "PENDING"
+	redemptionConfirmed = "CONFIRMED"
+	redemptionRejected  = "REJECTED"
+
+	redemptionKeyPrefix = "redemption_"
+)
+
+// Redemption is a customer's request to cash out coins. The coins are held from the balance
+// until the token owner confirms the off-chain payout, then burned.
+type Redemption struct {
+	RedemptionID    string `json:"redemption_id"` // reference ID quoted on the payout
+	TokenID         string `json:"token_id"`
+	CustomerAddress string `json:"customer_address"`
+	Amount          Amount `json:"amount"`
+	Status          string `json:"status"`                     // PENDING, CONFIRMED, REJECTED
+	PayoutReference string `json:"payout_reference,omitempty"` // owner's off-chain payment reference
+	Reason          string `json:"reason,omitempty"`
+	ProcessedBy     string `json:"processed_by,omitempty"`
+}
+
+func (s *SmartContract) getRedemption(ctx contractapi.TransactionContextInterface, redemptionID string) (*Redemption, error) {
+	b, err := ctx.GetStub().GetState(redemptionID)
+	if err != nil || b == nil || !strings.HasPrefix(redemptionID, redemptionKeyPrefix) {
+		return nil, fmt.Errorf("redemption not found")
+	}
+	var r Redemption
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *SmartContract) putRedemption(ctx contractapi.TransactionContextInterface, r *Redemption) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(r.RedemptionID, b)
+}
+
+// RequestRedemption holds amount coins from the customer's balance for an off-chain payout;
+// returns the redemption reference ID
+func (s *SmartContract) RequestRedemption(ctx contractapi.TransactionContextInterface, networkAddress, tokenID, passwordHash, amount string) (string, error) {
+	if err := s.requirePermission(ctx, permTransact); err != nil {
+		return "", err
+	}
+	passwordHash, err := s.secretInput(ctx, transientPasswordKey, passwordHash)
+	if err != nil {
+		return "", err
+	}
+
+	customerKey := "customer_" + networkAddress + "_" + tokenID
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
+		return "", err
+	}
+	if err := s.loadCustomerPII(ctx, customerKey, cust); err != nil {
+		return "", err
+	}
+	if err := verifySecret(cust.PasswordHash, cust.Credential, passwordHash); err != nil {
+		return "", err
+	}
+	if !cust.Approved || cust.Frozen {
+		return "", fmt.Errorf("customer not registered or approved for token")
+	}
+	if err := s.requireTokenOwnerNotFrozen(ctx, tokenID); err != nil {
+		return "", err
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	d := token.decimals()
+	value, err := parsePositiveAmount(amount, d)
+	if err != nil {
+		return "", err
+	}
+	if value.cmp(cust.Balance) > 0 {
+		return "", fmt.Errorf("insufficient balance: available %s, requested %s", cust.Balance.format(d), value.format(d))
+	}
+
+	redemptionID := redemptionKeyPrefix + ctx.GetStub().GetTxID()
+	existing, err := ctx.GetStub().GetState(redemptionID)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", fmt.Errorf("redemption %s already exists", redemptionID)
+	}
+	r := &Redemption{
+		RedemptionID:    redemptionID,
+		TokenID:         tokenID,
+		CustomerAddress: networkAddress,
+		Amount:          value,
+		Status:          redemptionPending,
+	}
+	if cust.Balance, err = cust.Balance.sub(value); err != nil {
+		return "", err
+	}
+	if cust.Redeeming, err = cust.Redeeming.add(value); err != nil {
+		return "", err
+	}
+	if err := s.putCustomer(ctx, customerKey, cust, false); err != nil {
+		return "", err
+	}
+	return r.RedemptionID, s.putRedemption(ctx, r)
+}
+
+// pendingRedemptionForOwner loads a pending redemption on a token owned by the caller
+func (s *SmartContract) pendingRedemptionForOwner(ctx contractapi.TransactionContextInterface, redemptionID, ownerNetworkAddress string) (*Redemption, *Participant, error) {
+	if err := s.requirePermission(ctx, permManageToken); err != nil {
+		return nil, nil, err
+	}
+	owner, err := s.callerParticipant(ctx, ownerNetworkAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkNotFrozen(owner); err != nil {
+		return nil, nil, err
+	}
+	r, err := s.getRedemption(ctx, redemptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := s.getToken(ctx, r.TokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token.Owner != owner.NetworkAddress {
+		return nil, nil, fmt.Errorf("caller is not token owner")
+	}
+	if r.Status != redemptionPending {
+		return nil, nil, fmt.Errorf("redemption already %s", strings.ToLower(r.Status))
+	}
+	return r, owner, nil
+}
+
+// ConfirmRedemption records the owner's off-chain payout and burns the held coins;
+// ownerNetworkAddress is optional
+func (s *SmartContract) ConfirmRedemption(ctx contractapi.TransactionContextInterface, redemptionID, payoutReference, ownerNetworkAddress string) error {
+	r, owner, err := s.pendingRedemptionForOwner(ctx, redemptionID, ownerNetworkAddress)
+	if err != nil {
+		return err
+	}
+	if payoutReference == "" {
+		return fmt.Errorf("payout reference is required")
+	}
+
+	customerKey := "customer_" + r.CustomerAddress + "_" + r.TokenID
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
+		return err
+	}
+	if cust.Redeeming, err = cust.Redeeming.sub(r.Amount); err != nil {
+		return err
+	}
+	if err := s.putCustomer(ctx, customerKey, cust, false); err != nil {
+		return err
+	}
+	token, err := s.getToken(ctx, r.TokenID)
+	if err != nil {
+		return err
+	}
+	token.TotalMinted = token.totalMinted()
+	if token.Burned, err = token.Burned.add(r.Amount); err != nil {
+		return err
+	}
+	if err := s.putToken(ctx, token); err != nil {
+		return err
+	}
+
+	r.Status = redemptionConfirmed
+	r.PayoutReference = payoutReference
+	r.ProcessedBy = owner.NetworkAddress
+	return s.putRedemption(ctx, r)
+}
+
+// RejectRedemption returns the held coins to the customer's balance; ownerNetworkAddress is optional
+func (s *SmartContract) RejectRedemption(ctx contractapi.TransactionContextInterface, redemptionID, reason, ownerNetworkAddress string) error {
+	r, owner, err := s.pendingRedemptionForOwner(ctx, redemptionID, ownerNetworkAddress)
+	if err != nil {
+		return err
+	}
+
+	customerKey := "customer_" + r.CustomerAddress + "_" + r.TokenID
+	cust, err := s.getCustomer(ctx, customerKey)
+	if err != nil {
+		return err
+	}
+	if cust.Redeeming, err = cust.Redeeming.sub(r.Amount); err != nil {
+		return err
+	}
+	if cust.Balance, err = cust.Balance.add(r.Amount); err != nil {
+		return err
+	}
+	if err := s.putCustomer(ctx, customerKey, cust, false); err != nil {
+		return err
+	}
+
+	r.Status = redemptionRejected
+	r.Reason = reason
+	r.ProcessedBy = owner.NetworkAddress
+	return s.putRedemption(ctx, r)
+}
+
+// GetTokenRedemptions lists a token's redemptions for reconciliation, optionally only those
+// with status; open to the token owner and admins
+func (s *SmartContract) GetTokenRedemptions(ctx contractapi.TransactionContextInterface, tokenID, status string) ([]Redemption, error) {
+	if s.VerifyAdmin(ctx) != nil {
+		if err := s.requirePermission(ctx, permManageToken); err != nil {
+			return nil, err
+		}
+		owner, err := s.callerParticipant(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		token, err := s.getToken(ctx, tokenID)
+		if err != nil {
+			return nil, err
+		}
+		if token.Owner != owner.NetworkAddress {
+			return nil, fmt.Errorf("caller is not token owner")
+		}
+	}
+
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var list []Redemption
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, redemptionKeyPrefix) {
+			var r Redemption
+			if err := json.Unmarshal(kv.Value, &r); err == nil && r.TokenID == tokenID && (status == "" || r.Status == status) {
+				list = append(list, r)
+			}
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupFundedCustomer gives customer carol of a token owned by alice a balance of 100
+func setupFundedCustomer(t *testing.T, h *TestHelper) string {
+	alice, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, alice, tokenID, "pass123", "100")
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+
+	setupCustomer(t, h, "alice-id", "carol", tokenID)
+	h.NewTx()
+	custMintID, err := h.Contract.CustomerRequestMint(h.Ctx, "carol", tokenID, "100")
+	assert.NoError(t, err)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveCustomerMint(h.Ctx, custMintID, ""))
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	return tokenID
+}
+
+func TestConfirmRedemptionBurnsCoins(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	tokenID := setupFundedCustomer(t, h)
+
+	_, err := h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "wrong", "30")
+	assert.Error(t, err)
+	_, err = h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "custpass", "101")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient balance")
+
+	h.NewTx()
+	redemptionID, err := h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "custpass", "30")
+	assert.NoError(t, err)
+	cust, err := h.GetCustomer("carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("70"), cust.Balance)
+	assert.Equal(t, Amount("30"), cust.Redeeming)
+
+	// Only the token owner confirms, quoting its payout
+	setupTokenOwner(t, h, "bob-id", "Bob")
+	err = h.Contract.ConfirmRedemption(h.Ctx, redemptionID, "PAY-1", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	assert.Error(t, h.Contract.ConfirmRedemption(h.Ctx, redemptionID, "PAY-1", ""))
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.Error(t, h.Contract.ConfirmRedemption(h.Ctx, redemptionID, "", ""))
+	assert.NoError(t, h.Contract.ConfirmRedemption(h.Ctx, redemptionID, "PAY-1", ""))
+
+	cust, err = h.GetCustomer("carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("70"), cust.Balance)
+	assert.Equal(t, zeroAmount, cust.Redeeming)
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("30"), token.Burned)
+	assert.Equal(t, Amount("100"), token.TotalMinted)
+
+	redemptions, err := h.Contract.GetTokenRedemptions(h.Ctx, tokenID, redemptionConfirmed)
+	assert.NoError(t, err)
+	if assert.Len(t, redemptions, 1) {
+		assert.Equal(t, "PAY-1", redemptions[0].PayoutReference)
+	}
+	err = h.Contract.RejectRedemption(h.Ctx, redemptionID, "late", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already confirmed")
+}
+
+func TestRejectRedemptionReturnsCoins(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	tokenID := setupFundedCustomer(t, h)
+
+	h.NewTx()
+	redemptionID, err := h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "custpass", "40")
+	assert.NoError(t, err)
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.RejectRedemption(h.Ctx, redemptionID, "bank details invalid", ""))
+
+	cust, err := h.GetCustomer("carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("100"), cust.Balance)
+	assert.Equal(t, zeroAmount, cust.Redeeming)
+	token, err := h.GetToken(tokenID)
+	assert.NoError(t, err)
+	assert.True(t, token.Burned.isZero())
+	assert.Error(t, h.Contract.ConfirmRedemption(h.Ctx, redemptionID, "PAY-2", ""))
+}
+
+func TestRedemptionReferenceIDsAreUnique(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	tokenID := setupFundedCustomer(t, h)
+
+	h.NewTx()
+	first, err := h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "custpass", "10")
+	assert.NoError(t, err)
+	_, err = h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "custpass", "10")
+	assert.Error(t, err, "one redemption per transaction")
+	assert.Contains(t, err.Error(), "already exists")
+	h.NewTx()
+	second, err := h.Contract.RequestRedemption(h.Ctx, "carol", tokenID, "custpass", "10")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	cust, err := h.GetCustomer("carol", tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("80"), cust.Balance)
+	assert.Equal(t, Amount("20"), cust.Redeeming)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Settlement modes accepted by RevokeToken
+const (
+	// revokeSettle zeroes customer balances and records a settlement for off-chain payout
+	revokeSettle = "SETTLE"
+	// revokeFreeze keeps customer balances on the ledger but blocks any further use
+	revokeFreeze = "FREEZE"
+
+	revocationKeyPrefix = "revocation_"
+	settlementKeyPrefix = "settlement_"
+)
+
+// TokenRevocation records what RevokeToken did to a token and its customers
+type TokenRevocation struct {
+	RevocationID      string `json:"revocation_id"`
+	TokenID           string `json:"token_id"`
+	PreviousOwner     string `json:"previous_owner"`
+	Mode              string `json:"mode"` // SETTLE or FREEZE
+	Reason            string `json:"reason"`
+	RevokedBy         string `json:"revoked_by"`
+	MintedForfeited   Amount `json:"minted_forfeited"` // owner's unissued coins at revocation
+	SettledCustomers  int    `json:"settled_customers"`
+	FrozenCustomers   int    `json:"frozen_customers"`
+	CancelledRequests int    `json:"cancelled_requests"`
+}
+
+// Settlement is a customer balance owed off-chain after its token was revoked
+type Settlement struct {
+	SettlementID    string `json:"settlement_id"`
+	TokenID         string `json:"token_id"`
+	CustomerAddress string `json:"customer_address"`
+	Amount          Amount `json:"amount"`
+	RevocationID    string `json:"revocation_id"`
+}
+
+// RevokeToken takes a token back from its owner and returns it to the pool, or to the first
+// waitlisted participant. Outstanding customer balances are settled or frozen according to
+// mode, and pending customer registrations, mint requests and transfers on the token are
+// cancelled.
+func (s *SmartContract) RevokeToken(ctx contractapi.TransactionContextInterface, tokenID, mode, reason string) (*TokenRevocation, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if mode != revokeSettle && mode != revokeFreeze {
+		return nil, fmt.Errorf("settlement mode must be %s or %s", revokeSettle, revokeFreeze)
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token.Owner == "" {
+		return nil, fmt.Errorf("token is not assigned")
+	}
+	adminID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, err
+	}
+
+	rev := &TokenRevocation{
+		RevocationID:    fmt.Sprintf("%s%s_%s", revocationKeyPrefix, tokenID, ctx.GetStub().GetTxID()),
+		TokenID:         tokenID,
+		PreviousOwner:   token.Owner,
+		Mode:            mode,
+		Reason:          reason,
+		RevokedBy:       adminID,
+		MintedForfeited: token.Minted,
+	}
+	if err := s.settleTokenRecords(ctx, rev); err != nil {
+		return nil, err
+	}
+
+	owner, err := s.getParticipant(ctx, token.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if owner.ownsToken(tokenID) {
+		owner.removeOwnedToken(tokenID)
+		if err := s.putParticipant(ctx, owner, false); err != nil {
+			return nil, err
+		}
+	}
+	r, err := s.approvedTokenRequest(ctx, token.Owner, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if r != nil {
+		r.Status = "REVOKED"
+		if err := s.putTokenRequest(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+
+	if token.Metadata != nil {
+		if holder, err := symbolOwner(ctx, token.Metadata.Symbol); err == nil && holder == tokenID {
+			if err := ctx.GetStub().DelState(symbolKey(token.Metadata.Symbol)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	token.Owner = ""
+	token.Available = true
+	token.TotalMinted = token.totalMinted()
+	token.Minted = zeroAmount
+	token.Metadata = nil
+	token.PendingMetadata = nil
+	token.MetadataRejection = ""
+	clearPause(token)
+	if err := s.putToken(ctx, token); err != nil {
+		return nil, err
+	}
+	if err := s.addTokenToPool(ctx, tokenID); err != nil {
+		return nil, err
+	}
+	if err := s.assignFromWaitlist(ctx, tokenID); err != nil {
+		return nil, err
+	}
+
+	rb, err := json.Marshal(rev)
+	if err != nil {
+		return nil, err
+	}
+	return rev, ctx.GetStub().PutState(rev.RevocationID, rb)
+}
+
+// settleTokenRecords settles or freezes the customers of rev.TokenID and cancels the
+// requests and redemptions still pending on it, counting each into rev
+func (s *SmartContract) settleTokenRecords(ctx contractapi.TransactionContextInterface, rev *TokenRevocation) error {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return err
+	}
+	var records []keyValue
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		records = append(records, keyValue{kv.Key, kv.Value})
+	}
+	iter.Close()
+
+	for _, kv := range records {
+		switch {
+		case strings.HasPrefix(kv.Key, "customer_"):
+			var cust Customer
+			if json.Unmarshal(kv.Value, &cust) != nil || cust.TokenID != rev.TokenID || !cust.Approved || cust.Frozen {
+				continue
+			}
+			if err := s.settleCustomer(ctx, kv.Key, &cust, rev); err != nil {
+				return err
+			}
+		case strings.HasPrefix(kv.Key, "custreq_"):
+			var req RegisterCustomerRequest
+			if json.Unmarshal(kv.Value, &req) != nil || req.TokenID != rev.TokenID || req.Approved || req.Cancelled {
+				continue
+			}
+			req.Cancelled = true
+			if err := s.putCustomerRequest(ctx, &req, false); err != nil {
+				return err
+			}
+			rev.CancelledRequests++
+		case strings.HasPrefix(kv.Key, "custmintreq_"), strings.HasPrefix(kv.Key, "mintrequest_"):
+			var r MintRequest
+			if json.Unmarshal(kv.Value, &r) != nil || r.TokenID != rev.TokenID || r.status() != mintRequestPending {
+				continue
+			}
+			r.close(mintRequestRejected, "token revoked")
+			if err := s.putMintRequest(ctx, &r); err != nil {
+				return err
+			}
+			rev.CancelledRequests++
+		case strings.HasPrefix(kv.Key, redemptionKeyPrefix):
+			var r Redemption
+			if json.Unmarshal(kv.Value, &r) != nil || r.TokenID != rev.TokenID || r.Status != redemptionPending {
+				continue
+			}
+			r.Status = redemptionRejected
+			r.Reason = "token revoked"
+			if err := s.putRedemption(ctx, &r); err != nil {
+				return err
+			}
+			rev.CancelledRequests++
+		case strings.HasPrefix(kv.Key, "transfer_"):
+			var t TransferRequest
+			if json.Unmarshal(kv.Value, &t) != nil || t.TokenID != rev.TokenID {
+				continue
+			}
+			if t.Status != "PendingOwnerApproval" && t.Status != "PendingReceiverApproval" {
+				continue
+			}
+			t.Status = "Cancelled"
+			b, _ := json.Marshal(t)
+			if err := ctx.GetStub().PutState(kv.Key, b); err != nil {
+				return err
+			}
+			rev.CancelledRequests++
+		}
+	}
+	return nil
+}
+
+// settleCustomer ends one customer relationship. Settled customers get a Settlement for
+// their balance and may register again with the next owner; frozen customers keep their
+// balance, locked, for off-chain resolution.
+func (s *SmartContract) settleCustomer(ctx contractapi.TransactionContextInterface, key string, cust *Customer, rev *TokenRevocation) error {
+	// Coins held for redemptions return to the balance; the redemptions are rejected
+	balance, err := cust.Balance.add(cust.Redeeming)
+	if err != nil {
+		return err
+	}
+	cust.Balance, cust.Redeeming = balance, zeroAmount
+	if rev.Mode == revokeFreeze {
+		cust.Frozen = true
+		rev.FrozenCustomers++
+		return s.putCustomer(ctx, key, cust, false)
+	}
+
+	if !cust.Balance.isZero() {
+		st := Settlement{
+			SettlementID:    fmt.Sprintf("%s%s_%s_%s", settlementKeyPrefix, rev.TokenID, cust.NetworkAddress, ctx.GetStub().GetTxID()),
+			TokenID:         rev.TokenID,
+			CustomerAddress: cust.NetworkAddress,
+			Amount:          cust.Balance,
+			RevocationID:    rev.RevocationID,
+		}
+		sb, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState(st.SettlementID, sb); err != nil {
+			return err
+		}
+	}
+	cust.Balance = zeroAmount
+	cust.Approved = false
+	if err := s.putCustomer(ctx, key, cust, false); err != nil {
+		return err
+	}
+	rev.SettledCustomers++
+
+	rb, err := ctx.GetStub().GetState("custreq_" + cust.NetworkAddress + "_" + rev.TokenID)
+	if err != nil || rb == nil {
+		return err
+	}
+	var req RegisterCustomerRequest
+	if err := json.Unmarshal(rb, &req); err != nil {
+		return err
+	}
+	req.Cancelled = true
+	return s.putCustomerRequest(ctx, &req, false)
+}
+
+// GetTokenRevocations lists the revocations recorded for tokenID (admin)
+func (s *SmartContract) GetTokenRevocations(ctx contractapi.TransactionContextInterface, tokenID string) ([]TokenRevocation, error) {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var revs []TokenRevocation
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, revocationKeyPrefix+tokenID+"_") {
+			var r TokenRevocation
+			if err := json.Unmarshal(kv.Value, &r); err == nil && r.TokenID == tokenID {
+				revs = append(revs, r)
+			}
+		}
+	}
+	return revs, nil
+}
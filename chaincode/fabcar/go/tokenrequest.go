@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	tokenRequestRejected  = "REJECTED"
+	tokenRequestCancelled = "CANCELLED"
+
+	// tokenRequestHistoryPrefix keys the closed token requests a participant filed before its
+	// current one, by participant and request sequence
+	tokenRequestHistoryPrefix = "tokenreqhistory_"
+)
+
+func tokenRequestHistoryKey(networkAddress string, sequence int) string {
+	return fmt.Sprintf("%s%s_%06d", tokenRequestHistoryPrefix, networkAddress, sequence)
+}
+
+// openTokenRequest reports whether r still waits for an admin decision or a token
+func openTokenRequest(r *TokenRequest) bool {
+	return r.Status == "PENDING" || r.Status == tokenRequestWaitlisted
+}
+
+func (s *SmartContract) getTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress string) (*TokenRequest, error) {
+	rb, err := ctx.GetStub().GetState("tokenrequest_" + networkAddress)
+	if err != nil || rb == nil {
+		return nil, fmt.Errorf("token request not found")
+	}
+	var r TokenRequest
+	if err := json.Unmarshal(rb, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *SmartContract) putTokenRequest(ctx contractapi.TransactionContextInterface, r *TokenRequest) error {
+	rb, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(r.RequestID, rb)
+}
+
+// fileTokenRequest stores r in its participant's history under its sequence number
+func (s *SmartContract) fileTokenRequest(ctx contractapi.TransactionContextInterface, r *TokenRequest) error {
+	// Requests filed before history was kept are the participant's first
+	if r.Sequence == 0 {
+		r.Sequence = 1
+	}
+	r.RequestID = tokenRequestHistoryKey(r.NetworkAddr, r.Sequence)
+	return s.putTokenRequest(ctx, r)
+}
+
+// listTokenRequestHistory returns the participant's filed token requests, oldest first
+func (s *SmartContract) listTokenRequestHistory(ctx contractapi.TransactionContextInterface, networkAddress string) ([]TokenRequest, error) {
+	iter, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	list := []TokenRequest{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kv.Key, tokenRequestHistoryPrefix+networkAddress+"_") {
+			var r TokenRequest
+			if err := json.Unmarshal(kv.Value, &r); err == nil && r.NetworkAddr == networkAddress {
+				list = append(list, r)
+			}
+		}
+	}
+	return list, nil
+}
+
+// archiveTokenRequest moves the participant's rejected or cancelled current request into its
+// history and returns the sequence number of the next request. Open and approved requests are
+// never replaced; approved ones are filed in the history when they are approved.
+func (s *SmartContract) archiveTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress string) (int, error) {
+	rb, err := ctx.GetStub().GetState("tokenrequest_" + networkAddress)
+	if err != nil {
+		return 0, err
+	}
+	if rb == nil {
+		history, err := s.listTokenRequestHistory(ctx, networkAddress)
+		if err != nil {
+			return 0, err
+		}
+		next := 1
+		for _, r := range history {
+			if r.Sequence >= next {
+				next = r.Sequence + 1
+			}
+		}
+		return next, nil
+	}
+	var r TokenRequest
+	if err := json.Unmarshal(rb, &r); err != nil {
+		return 0, err
+	}
+	if r.Status != tokenRequestRejected && r.Status != tokenRequestCancelled {
+		return 0, fmt.Errorf("token request already %s", strings.ToLower(r.Status))
+	}
+	if err := s.fileTokenRequest(ctx, &r); err != nil {
+		return 0, err
+	}
+	return r.Sequence + 1, nil
+}
+
+// approvedTokenRequest finds the approved request through which networkAddress got tokenID,
+// nil if there is none
+func (s *SmartContract) approvedTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress, tokenID string) (*TokenRequest, error) {
+	// Requests approved before they were filed on approval stay current
+	if r, err := s.getTokenRequest(ctx, networkAddress); err == nil && r.Status == "APPROVED" && r.TokenID == tokenID {
+		return r, nil
+	}
+	history, err := s.listTokenRequestHistory(ctx, networkAddress)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Status == "APPROVED" && history[i].TokenID == tokenID {
+			return &history[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// closeTokenRequest ends an open request with status, taking it off the waitlist
+func (s *SmartContract) closeTokenRequest(ctx contractapi.TransactionContextInterface, r *TokenRequest, status, reason string) error {
+	if !openTokenRequest(r) {
+		return fmt.Errorf("request already processed")
+	}
+	if r.WaitlistedAt != "" {
+		key, err := waitlistKey(ctx, r.WaitlistedAt, r.NetworkAddr)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return err
+		}
+	}
+	r.Status = status
+	r.Reason = reason
+	r.WaitlistedAt = ""
+	return s.putTokenRequest(ctx, r)
+}
+
+// RejectTokenRequest lets an admin turn down a pending or waitlisted token request
+func (s *SmartContract) RejectTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress, reason string) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("rejection reason is required")
+	}
+	r, err := s.getTokenRequest(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	return s.closeTokenRequest(ctx, r, tokenRequestRejected, reason)
+}
+
+// CancelTokenRequest withdraws the caller's own pending or waitlisted token request;
+// networkAddress is optional
+func (s *SmartContract) CancelTokenRequest(ctx contractapi.TransactionContextInterface, networkAddress string) error {
+	if err := s.requirePermission(ctx, permParticipate); err != nil {
+		return err
+	}
+	p, err := s.callerParticipant(ctx, networkAddress)
+	if err != nil {
+		return err
+	}
+	r, err := s.getTokenRequest(ctx, p.NetworkAddress)
+	if err != nil {
+		return err
+	}
+	return s.closeTokenRequest(ctx, r, tokenRequestCancelled, "")
+}
+
+// GetTokenRequestHistory lists every token request of a participant, oldest first, ending
+// with the current one; open to the participant and admins
+func (s *SmartContract) GetTokenRequestHistory(ctx contractapi.TransactionContextInterface, networkAddress string) ([]TokenRequest, error) {
+	p, err := s.callerParticipant(ctx, networkAddress)
+	if err == nil {
+		err = s.requirePermission(ctx, permParticipate)
+	} else if adminErr := s.VerifyAdmin(ctx); adminErr == nil {
+		p, err = s.getParticipant(ctx, networkAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.listTokenRequestHistory(ctx, p.NetworkAddress)
+	if err != nil {
+		return nil, err
+	}
+	if current, err := s.getTokenRequest(ctx, p.NetworkAddress); err == nil {
+		list = append(list, *current)
+	}
+	return list, nil
+}
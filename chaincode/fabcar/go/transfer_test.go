@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTokenOwner registers, verifies and assigns a token to a participant acting as identity id
+func setupTokenOwner(t *testing.T, h *TestHelper, id, name string) (string, string) {
+	h.SetIdentity(id, "Org1MSP", "token_owner")
+	netAddr, err := h.CreateParticipant(name, "pass123", "USA")
+	assert.NoError(t, err)
+	assert.NoError(t, h.VerifyParticipant(netAddr))
+	assert.NoError(t, h.Contract.RequestTokenRequest(h.Ctx, name, netAddr, "pass123", "USA", ""))
+
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveTokenRequest(h.Ctx, netAddr))
+	p, err := h.GetParticipant(netAddr)
+	assert.NoError(t, err)
+	h.SetIdentity(id, "Org1MSP", "token_owner")
+	return netAddr, p.TokenIDs[len(p.TokenIDs)-1]
+}
+
+// fundToken mints amount unissued coins onto the owner's token through a mint request
+func fundToken(t *testing.T, h *TestHelper, ownerID, tokenID, amount string) {
+	h.SetIdentity(ownerID, "Org1MSP", "token_owner")
+	h.NewTx()
+	mintReqID, err := h.Contract.RequestMintCoins(h.Ctx, "", tokenID, "pass123", amount)
+	assert.NoError(t, err)
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.ApproveMintRequest(h.Ctx, mintReqID))
+	h.SetIdentity(ownerID, "Org1MSP", "token_owner")
+}
+
+func TestTransferUnderRoleMapping(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	alice, aliceToken := setupTokenOwner(t, h, "alice-id", "Alice")
+	_, bobToken := setupTokenOwner(t, h, "bob-id", "Bob")
+	fundToken(t, h, "alice-id", aliceToken, "100")
+
+	// Customers hold no participant token to send from
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	_, err := h.Contract.CreateTransferRequest(h.Ctx, alice, "", aliceToken, "", bobToken, "40")
+	assert.Error(t, err)
+
+	// Senders only spend the unissued coins of a token they own
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.CreateTransferRequest(h.Ctx, "", "", aliceToken, "", bobToken, "40")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not token owner")
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	_, err = h.Contract.CreateTransferRequest(h.Ctx, "", "", aliceToken, "", bobToken, "101")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient funds")
+	_, err = h.Contract.CreateTransferRequest(h.Ctx, "", "", aliceToken, "", aliceToken, "10")
+	assert.Error(t, err)
+
+	// Each step is taken by a certificate carrying nothing but the token_owner role
+	transferID, err := h.Contract.CreateTransferRequest(h.Ctx, "", "", aliceToken, "", bobToken, "40")
+	assert.NoError(t, err)
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	err = h.Contract.ApproveTransferByOwner(h.Ctx, transferID, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not the sender")
+	err = h.Contract.ApproveTransferByReceiver(h.Ctx, transferID, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not pending receiver approval")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveTransferByOwner(h.Ctx, transferID, ""))
+	err = h.Contract.ApproveTransferByReceiver(h.Ctx, transferID, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not the token owner")
+
+	h.SetIdentity("bob-id", "Org1MSP", "token_owner")
+	assert.NoError(t, h.Contract.ApproveTransferByReceiver(h.Ctx, transferID, ""))
+
+	var request TransferRequest
+	assert.NoError(t, json.Unmarshal(h.Stub.State[transferID], &request))
+	assert.Equal(t, "Completed", request.Status)
+	assert.Equal(t, alice, request.SenderTransferID)
+	sent, err := h.GetToken(aliceToken)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("60"), sent.Minted)
+	assert.Equal(t, Amount("100"), sent.TotalMinted)
+	received, err := h.GetToken(bobToken)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount("40"), received.Minted)
+
+	// The sender's participant record is untouched by the transfer
+	p, err := h.GetParticipant(alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", p.Name)
+}
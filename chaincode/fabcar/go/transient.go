@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Transient map schema for secrets and personal details. Values are the raw UTF-8 bytes of the
+// input; transient data is passed to endorsers but never written to blocks.
+//
+//	password_hash      account secret of every method taking a passwordHash or currentPassword argument
+//	new_password_hash  replacement secret for ChangePassword, ResetPassword and their customer variants
+//	pincode            pincode of RequestTokenRequest
+//	name               name of SubmitRegistration, RegisterCustomer and RequestTokenRequest
+//	country            country of SubmitRegistration and RequestTokenRequest
+//
+// Callers using the transient map pass an empty string for the matching argument. The argument
+// form is accepted only while the legacy switch is on, see SetLegacyCredentialArgs.
+const (
+	transientPasswordKey    = "password_hash"
+	transientNewPasswordKey = "new_password_hash"
+	transientPincodeKey     = "pincode"
+	transientNameKey        = "name"
+	transientCountryKey     = "country"
+)
+
+const legacyCredentialArgsKey = "config_legacy_credential_args"
+
+// secretInput returns the secret stored under key in the transient map, falling back to the
+// argument value while legacy argument credentials are allowed
+func (s *SmartContract) secretInput(ctx contractapi.TransactionContextInterface, key, argValue string) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("unable to read transient map: %v", err)
+	}
+	if v, ok := transient[key]; ok && len(v) > 0 {
+		if argValue != "" {
+			return "", fmt.Errorf("%s must not be passed both as argument and in the transient map", key)
+		}
+		return string(v), nil
+	}
+
+	if argValue == "" {
+		return "", fmt.Errorf("%s must be provided in the transient map", key)
+	}
+	allowed, err := s.legacyCredentialArgsAllowed(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", fmt.Errorf("%s must be provided in the transient map: argument form is disabled", key)
+	}
+	return argValue, nil
+}
+
+// legacyCredentialArgsAllowed reports whether secrets may still be passed as arguments;
+// it defaults to true until an admin turns it off
+func (s *SmartContract) legacyCredentialArgsAllowed(ctx contractapi.TransactionContextInterface) (bool, error) {
+	b, err := ctx.GetStub().GetState(legacyCredentialArgsKey)
+	if err != nil {
+		return false, err
+	}
+	return b == nil || string(b) == "true", nil
+}
+
+// SetLegacyCredentialArgs lets an admin allow or refuse secrets passed as transaction arguments
+func (s *SmartContract) SetLegacyCredentialArgs(ctx contractapi.TransactionContextInterface, enabled bool) error {
+	if err := s.VerifyAdmin(ctx); err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(legacyCredentialArgsKey, []byte(fmt.Sprintf("%t", enabled)))
+}
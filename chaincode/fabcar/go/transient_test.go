@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerDetailsFromTransientMap(t *testing.T) {
+	h := NewTestHelper()
+	assert.NoError(t, h.InitLedgerWithTokens())
+	_, tokenID := setupTokenOwner(t, h, "alice-id", "Alice")
+
+	h.SetIdentity("carol-id", "Org1MSP", "customer")
+	h.SetTransient(map[string]string{transientPasswordKey: "custpass", transientNameKey: "Carol Jones"})
+	assert.NoError(t, h.Contract.RegisterCustomer(h.Ctx, "carol", "", "", tokenID))
+	reqID := "custreq_carol_" + tokenID
+	assert.NotContains(t, string(h.Stub.State[reqID]), "Carol Jones")
+
+	h.SetIdentity("alice-id", "Org1MSP", "token_owner")
+	pending, err := h.Contract.ViewPendingCustomerRegistrations(h.Ctx, tokenID, "")
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, "Carol Jones", pending[0].Name)
+	}
+
+	// With the legacy switch off a name passed as argument is refused
+	h.SetAsAdmin()
+	assert.NoError(t, h.Contract.SetLegacyCredentialArgs(h.Ctx, false))
+	h.SetIdentity("dave-id", "Org1MSP", "customer")
+	h.SetTransient(map[string]string{transientPasswordKey: "custpass"})
+	err = h.Contract.RegisterCustomer(h.Ctx, "dave", "Dave", "", tokenID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "argument form is disabled")
+}
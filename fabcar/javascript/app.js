@@ -23,10 +23,22 @@ async function connect(walletPath, userId) {
     return { gateway, contract };
 }
 
+// transientData encodes secrets and personal details for the transient map, which reaches the
+// endorsing peers but is never written to a block; the matching arguments are passed empty
+function transientData(values) {
+    const data = {};
+    for (const [key, value] of Object.entries(values)) {
+        data[key] = Buffer.from(value || '');
+    }
+    return data;
+}
+
 async function submitRegistration(name, passwordHash, country, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        const result = await contract.submitTransaction('SubmitRegistration', name, passwordHash, country);
+        const result = await contract.createTransaction('SubmitRegistration')
+            .setTransient(transientData({ name, password_hash: passwordHash, country }))
+            .submit('', '', '');
         console.log(`SubmitRegistration result: ${result.toString()}`);
         return result.toString();
     } finally {
@@ -44,10 +56,12 @@ async function participantExists(networkAddress, walletPath, userId) {
     }
 }
 
-async function requestTokenRequest(name, networkAddress, passwordHash, country, walletPath, userId) {
+async function requestTokenRequest(name, networkAddress, passwordHash, country, purpose, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        await contract.submitTransaction('RequestTokenRequest', name, networkAddress, passwordHash, country);
+        await contract.createTransaction('RequestTokenRequest')
+            .setTransient(transientData({ name, password_hash: passwordHash, country }))
+            .submit('', networkAddress, '', '', purpose || '');
         console.log('Token request submitted');
     } finally {
         gateway.disconnect();
@@ -74,21 +88,26 @@ async function approveTokenRequest(networkAddress, walletPath, adminId) {
     }
 }
 
-async function getTokenAccess(networkAddress, passwordHash, walletPath, userId) {
+async function getTokenAccess(networkAddress, tokenID, passwordHash, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        const result = await contract.evaluateTransaction('GetTokenAccess', networkAddress, passwordHash);
+        const result = await contract.createTransaction('GetTokenAccess')
+            .setTransient(transientData({ password_hash: passwordHash }))
+            .evaluate(networkAddress, tokenID, '');
         return result.toString();
     } finally {
         gateway.disconnect();
     }
 }
 
-async function requestMintCoins(networkAddress, passwordHash, amount, walletPath, userId) {
+async function requestMintCoins(networkAddress, tokenID, passwordHash, amount, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        await contract.submitTransaction('RequestMintCoins', networkAddress, passwordHash, amount.toString());
-        console.log('Mint request submitted');
+        const requestID = await contract.createTransaction('RequestMintCoins')
+            .setTransient(transientData({ password_hash: passwordHash }))
+            .submit(networkAddress, tokenID, '', amount.toString());
+        console.log(`Mint request submitted: ${requestID.toString()}`);
+        return requestID.toString();
     } finally {
         gateway.disconnect();
     }
@@ -114,10 +133,12 @@ async function approveMintRequest(requestId, walletPath, adminId) {
     }
 }
 
-async function getWalletInfo(networkAddress, passwordHash, walletPath, userId) {
+async function getWalletInfo(networkAddress, tokenID, passwordHash, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        const result = await contract.evaluateTransaction('GetWalletInfo', networkAddress, passwordHash);
+        const result = await contract.createTransaction('GetWalletInfo')
+            .setTransient(transientData({ password_hash: passwordHash }))
+            .evaluate(networkAddress, tokenID, '');
         return JSON.parse(result.toString());
     } finally {
         gateway.disconnect();
@@ -137,7 +158,9 @@ async function viewAllTokens(walletPath, userId) {
 async function registerCustomer(networkAddress, name, passwordHash, tokenID, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        await contract.submitTransaction('RegisterCustomer', networkAddress, name, passwordHash, tokenID);
+        await contract.createTransaction('RegisterCustomer')
+            .setTransient(transientData({ name, password_hash: passwordHash }))
+            .submit(networkAddress, '', '', tokenID);
         console.log('Customer registration submitted');
     } finally {
         gateway.disconnect();
@@ -167,8 +190,9 @@ async function approveCustomerRegistration(requestId, ownerNetworkAddress, walle
 async function customerRequestMint(networkAddress, tokenID, amount, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        await contract.submitTransaction('CustomerRequestMint', networkAddress, tokenID, amount.toString());
-        console.log('Customer mint request submitted');
+        const requestID = await contract.submitTransaction('CustomerRequestMint', networkAddress, tokenID, amount.toString());
+        console.log(`Customer mint request submitted: ${requestID.toString()}`);
+        return requestID.toString();
     } finally {
         gateway.disconnect();
     }
@@ -197,7 +221,9 @@ async function approveCustomerMint(requestId, ownerNetworkAddress, walletPath, u
 async function viewCustomerWallet(networkAddress, tokenID, passwordHash, walletPath, userId) {
     const { gateway, contract } = await connect(walletPath, userId);
     try {
-        const result = await contract.evaluateTransaction('ViewCustomerWallet', networkAddress, tokenID, passwordHash);
+        const result = await contract.createTransaction('ViewCustomerWallet')
+            .setTransient(transientData({ password_hash: passwordHash }))
+            .evaluate(networkAddress, tokenID, '');
         return JSON.parse(result.toString());
     } finally {
         gateway.disconnect();
@@ -112,14 +112,15 @@ class FabCarClient {
     /**
      * Request a token
      */
-    async requestToken(name, networkAddress, password, country) {
+    async requestToken(name, networkAddress, password, country, purpose) {
         try {
             const response = await makeRequest('POST', '/token-request', {
                 userId: this.userId,
                 name,
                 networkAddress,
                 password,
-                country
+                country,
+                purpose
             });
             console.log('✓ Token request submitted:', response);
             return response;
@@ -162,11 +163,12 @@ class FabCarClient {
     /**
      * Get token access
      */
-    async getTokenAccess(networkAddress, password) {
+    async getTokenAccess(networkAddress, tokenID, password) {
         try {
             const response = await makeRequest('POST', '/token-access', {
                 userId: this.userId,
                 networkAddress,
+                tokenID,
                 password
             });
             console.log('✓ Token access:', response);
@@ -180,11 +182,12 @@ class FabCarClient {
     /**
      * Request to mint coins
      */
-    async requestMint(networkAddress, password, amount) {
+    async requestMint(networkAddress, tokenID, password, amount) {
         try {
             const response = await makeRequest('POST', '/mint-request', {
                 userId: this.userId,
                 networkAddress,
+                tokenID,
                 password,
                 amount
             });
@@ -229,9 +232,9 @@ class FabCarClient {
     /**
      * Get wallet information
      */
-    async getWalletInfo(networkAddress, password) {
+    async getWalletInfo(networkAddress, tokenID, password) {
         try {
-            const response = await makeRequest('GET', `/wallet/${networkAddress}?userId=${this.userId}&password=${password}`);
+            const response = await makeRequest('GET', `/wallet/${networkAddress}?userId=${this.userId}&tokenID=${tokenID}&password=${password}`);
             console.log('✓ Wallet info:', response);
             return response;
         } catch (error) {
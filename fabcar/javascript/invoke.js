@@ -28,16 +28,30 @@ async function connect() {
   return { gateway, contract };
 }
 
+// transientData encodes secrets and personal details for the transient map, which reaches the
+// endorsing peers but is never written to a block; the matching arguments are passed empty
+function transientData(values) {
+  const data = {};
+  for (const [key, value] of Object.entries(values)) {
+    data[key] = Buffer.from(value || '');
+  }
+  return data;
+}
+
 async function submitRegistration(name, passwordHash, country) {
   const { gateway, contract } = await connect();
-  await contract.submitTransaction('SubmitRegistration', name, passwordHash, country);
+  await contract.createTransaction('SubmitRegistration')
+    .setTransient(transientData({ name, password_hash: passwordHash, country }))
+    .submit('', '', '');
   console.log('SubmitRegistration transaction has been submitted');
   gateway.disconnect();
 }
 
-async function requestTokenRequest(name, networkAddress, passwordHash, country) {
+async function requestTokenRequest(name, networkAddress, passwordHash, country, purpose = '') {
   const { gateway, contract } = await connect();
-  await contract.submitTransaction('RequestTokenRequest', name, networkAddress, passwordHash, country);
+  await contract.createTransaction('RequestTokenRequest')
+    .setTransient(transientData({ name, password_hash: passwordHash, country }))
+    .submit('', networkAddress, '', '', purpose);
   console.log('RequestTokenRequest transaction has been submitted');
   gateway.disconnect();
 }
@@ -49,9 +63,11 @@ async function approveTokenRequest(networkAddress) {
   gateway.disconnect();
 }
 
-async function requestMintCoins(networkAddress, passwordHash, amount) {
+async function requestMintCoins(networkAddress, tokenID, passwordHash, amount) {
   const { gateway, contract } = await connect();
-  await contract.submitTransaction('RequestMintCoins', networkAddress, passwordHash, amount.toString());
+  await contract.createTransaction('RequestMintCoins')
+    .setTransient(transientData({ password_hash: passwordHash }))
+    .submit(networkAddress, tokenID, '', amount.toString());
   console.log('RequestMintCoins transaction has been submitted');
   gateway.disconnect();
 }
@@ -65,7 +81,9 @@ async function approveMintRequest(requestID) {
 
 async function registerCustomer(networkAddress, name, passwordHash, tokenID) {
   const { gateway, contract } = await connect();
-  await contract.submitTransaction('RegisterCustomer', networkAddress, name, passwordHash, tokenID);
+  await contract.createTransaction('RegisterCustomer')
+    .setTransient(transientData({ name, password_hash: passwordHash }))
+    .submit(networkAddress, '', '', tokenID);
   console.log('RegisterCustomer transaction has been submitted');
   gateway.disconnect();
 }
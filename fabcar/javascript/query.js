@@ -42,9 +42,9 @@ async function getPendingMintRequests() {
   return JSON.parse(result.toString());
 }
 
-async function getWalletInfo(networkAddress, passwordHash) {
+async function getWalletInfo(networkAddress, tokenID, passwordHash) {
   const { gateway, contract } = await connect();
-  const result = await contract.evaluateTransaction('GetWalletInfo', networkAddress, passwordHash);
+  const result = await contract.evaluateTransaction('GetWalletInfo', networkAddress, tokenID, passwordHash);
   gateway.disconnect();
   return JSON.parse(result.toString());
 }
@@ -16,9 +16,11 @@ async function main() {
   try {
     const name = process.argv[2];
     const password = process.argv[3];
+    // Role certificate attribute checked by the chaincode: admin, token_owner, customer or auditor
+    const role = process.argv[4] || 'token_owner';
 
     if (!name || !password) {
-      console.error('Usage: node registerUser.js <name> <password>');
+      console.error('Usage: node registerUser.js <name> <password> [role]');
       process.exit(1);
     }
 
@@ -51,7 +53,12 @@ async function main() {
     const adminUser = await provider.getUserContext(adminIdentity, 'admin');
 
     // Register the user (enrollment secret)
-    const secret = await ca.register({ affiliation: 'org1.department1', enrollmentID: name, role: 'client' }, adminUser);
+    const secret = await ca.register({
+      affiliation: 'org1.department1',
+      enrollmentID: name,
+      role: 'client',
+      attrs: [{ name: 'role', value: role, ecert: true }],
+    }, adminUser);
 
     // Enroll the user using secret
     const enrollment = await ca.enroll({ enrollmentID: name, enrollmentSecret: secret });
@@ -70,12 +77,10 @@ async function main() {
     // Compute SHA256 password hash
     const passwordHash = crypto.createHash('sha256').update(password).digest('hex');
 
-    // Compute user network address (SHA256 of name)
-    const nameHash = crypto.createHash('sha256').update(name).digest('hex');
-
     console.log(`User "${name}" registered and enrolled successfully.`);
-    console.log(`Wallet identity created for user "${name}".`);
-    console.log(`Network Address (SHA256 hash of name): ${nameHash}`);
+    console.log(`Wallet identity created for user "${name}" with role "${role}".`);
+    // The chaincode derives the network address from the caller's certificate when registering
+    console.log('Network Address: returned by SubmitRegistration when this identity registers as a participant');
     console.log(`Password Hash (SHA256): ${passwordHash}`);
 
   } catch (error) {
@@ -367,7 +367,7 @@ app.get('/api/participant/:networkAddress', async (req, res) => {
  */
 app.post('/api/token-request', async (req, res) => {
     try {
-        const { userId, name, networkAddress, password, country } = req.body;
+        const { userId, name, networkAddress, password, country, purpose } = req.body;
 
         if (!userId || !name || !networkAddress || !password || !country) {
             return res.status(400).json({
@@ -379,7 +379,7 @@ app.post('/api/token-request', async (req, res) => {
         const passwordHash = hashPassword(password);
         
         if (Object.keys(appFunctions).length > 0 && appFunctions.requestTokenRequest) {
-            await appFunctions.requestTokenRequest(name, networkAddress, passwordHash, country, walletPath, userId);
+            await appFunctions.requestTokenRequest(name, networkAddress, passwordHash, country, purpose, walletPath, userId);
         }
 
         res.json({
@@ -402,7 +402,7 @@ app.post('/api/token-request', async (req, res) => {
  */
 app.post('/api/auth/login', async (req, res) => {
     try {
-        const { network_address, password } = req.body;
+        const { network_address, password, token_id } = req.body;
 
         if (!network_address || !password) {
             return res.status(400).json({ success: false, detail: 'Missing network_address or password' });
@@ -413,7 +413,7 @@ app.post('/api/auth/login', async (req, res) => {
         // Attempt to get token access (production) — falls back to demo
         if (Object.keys(appFunctions).length > 0 && appFunctions.getTokenAccess) {
             try {
-                const access = await appFunctions.getTokenAccess(network_address, passwordHash, walletPath, 'web');
+                const access = await appFunctions.getTokenAccess(network_address, token_id || '', passwordHash, walletPath, 'web');
                 // Map access to frontend shape
                 const token = access && access.tokenID ? access.tokenID : ('token-' + crypto.randomBytes(8).toString('hex'));
                 const userObj = {
@@ -509,19 +509,19 @@ app.post('/api/token-requests/:networkAddress/approve', async (req, res) => {
  */
 app.post('/api/token-access', async (req, res) => {
     try {
-        const { userId, networkAddress, password } = req.body;
+        const { userId, networkAddress, tokenID, password } = req.body;
 
-        if (!userId || !networkAddress || !password) {
+        if (!userId || !networkAddress || !tokenID || !password) {
             return res.status(400).json({
                 success: false,
-                error: 'Missing required fields: userId, networkAddress, password'
+                error: 'Missing required fields: userId, networkAddress, tokenID, password'
             });
         }
 
         const passwordHash = hashPassword(password);
         
         if (Object.keys(appFunctions).length > 0 && appFunctions.getTokenAccess) {
-            const access = await appFunctions.getTokenAccess(networkAddress, passwordHash, walletPath, userId);
+            const access = await appFunctions.getTokenAccess(networkAddress, tokenID, passwordHash, walletPath, userId);
             res.json({ success: true, access });
         } else {
             res.json({ success: true, access: { tokenID: 'TOKEN-DEMO', approved: true }, mode: 'demo' });
@@ -543,9 +543,9 @@ app.post('/api/token-access', async (req, res) => {
  */
 app.post('/api/mint-request', async (req, res) => {
     try {
-        const { userId, networkAddress, password, amount } = req.body;
+        const { userId, networkAddress, tokenID, password, amount } = req.body;
 
-        if (!userId || !networkAddress || !password || !amount) {
+        if (!userId || !networkAddress || !tokenID || !password || !amount) {
             return res.status(400).json({
                 success: false,
                 error: 'Missing required fields'
@@ -555,7 +555,7 @@ app.post('/api/mint-request', async (req, res) => {
         const passwordHash = hashPassword(password);
         
         if (Object.keys(appFunctions).length > 0 && appFunctions.requestMintCoins) {
-            await appFunctions.requestMintCoins(networkAddress, passwordHash, amount, walletPath, userId);
+            await appFunctions.requestMintCoins(networkAddress, tokenID, passwordHash, amount, walletPath, userId);
         }
 
         res.json({
@@ -767,19 +767,19 @@ app.post('/api/wallet/import-identity', async (req, res) => {
 app.get('/api/wallet/:networkAddress', async (req, res) => {
     try {
         const { networkAddress } = req.params;
-        const { userId, password } = req.query;
+        const { userId, tokenID, password } = req.query;
 
-        if (!userId || !password) {
+        if (!userId || !tokenID || !password) {
             return res.status(400).json({
                 success: false,
-                error: 'Missing userId or password'
+                error: 'Missing userId, tokenID or password'
             });
         }
 
         const passwordHash = hashPassword(password);
         
         if (Object.keys(appFunctions).length > 0 && appFunctions.getWalletInfo) {
-            const walletInfo = await appFunctions.getWalletInfo(networkAddress, passwordHash, walletPath, userId);
+            const walletInfo = await appFunctions.getWalletInfo(networkAddress, tokenID, passwordHash, walletPath, userId);
             res.json({ success: true, walletInfo });
         } else {
             res.json({ success: true, walletInfo: { balance: 0, tokenID: 'TOKEN-DEMO' }, mode: 'demo' });
@@ -28,10 +28,22 @@ function hashPassword(password) {
   return crypto.createHash('sha256').update(password).digest('hex');
 }
 
+// transientData encodes secrets and personal details for the transient map, which reaches the
+// endorsing peers but is never written to a block; the matching arguments are passed empty
+function transientData(values) {
+  const data = {};
+  for (const [key, value] of Object.entries(values)) {
+    data[key] = Buffer.from(value || '');
+  }
+  return data;
+}
+
 async function submitRegistration(name, passwordHash, country) {
   const { contract, gateway } = await getContract(name);
   try {
-    await contract.submitTransaction('SubmitRegistration', name, passwordHash, country);
+    await contract.createTransaction('SubmitRegistration')
+      .setTransient(transientData({ name, password_hash: passwordHash, country }))
+      .submit('', '', '');
     console.log('SubmitRegistration transaction has been submitted');
   } finally {
     gateway.disconnect();
@@ -52,10 +64,12 @@ async function participantExists(networkAddress) {
   }
 }
 
-async function requestTokenRequest(name, networkAddress, passwordHash, country) {
+async function requestTokenRequest(name, networkAddress, passwordHash, country, purpose = '') {
   const { contract, gateway } = await getContract(name);
   try {
-    await contract.submitTransaction('RequestTokenRequest', name, networkAddress, passwordHash, country);
+    await contract.createTransaction('RequestTokenRequest')
+      .setTransient(transientData({ name, password_hash: passwordHash, country }))
+      .submit('', networkAddress, '', '', purpose);
     console.log('RequestTokenRequest transaction has been submitted');
   } finally {
     gateway.disconnect();
@@ -72,10 +86,12 @@ async function approveTokenRequest(networkAddress) {
   }
 }
 
-async function requestMintCoins(networkAddress, passwordHash, amount) {
+async function requestMintCoins(networkAddress, tokenID, passwordHash, amount) {
   const { contract, gateway } = await getContract(networkAddress);
   try {
-    await contract.submitTransaction('RequestMintCoins', networkAddress, passwordHash, amount.toString());
+    await contract.createTransaction('RequestMintCoins')
+      .setTransient(transientData({ password_hash: passwordHash }))
+      .submit(networkAddress, tokenID, '', amount.toString());
     console.log('RequestMintCoins transaction has been submitted');
   } finally {
     gateway.disconnect();
@@ -95,7 +111,9 @@ async function approveMintRequest(requestID) {
 async function registerCustomer(networkAddress, name, passwordHash, tokenID) {
   const { contract, gateway } = await getContract(name);
   try {
-    await contract.submitTransaction('RegisterCustomer', networkAddress, name, passwordHash, tokenID);
+    await contract.createTransaction('RegisterCustomer')
+      .setTransient(transientData({ name, password_hash: passwordHash }))
+      .submit(networkAddress, '', '', tokenID);
     console.log('RegisterCustomer transaction has been submitted');
   } finally {
     gateway.disconnect();
@@ -190,10 +208,12 @@ async function getPendingMintRequests() {
   }
 }
 
-async function getWalletInfo(networkAddress, passwordHash) {
+async function getWalletInfo(networkAddress, tokenID, passwordHash) {
   const { contract, gateway } = await getContract(networkAddress);
   try {
-    const result = await contract.evaluateTransaction('GetWalletInfo', networkAddress, passwordHash);
+    const result = await contract.createTransaction('GetWalletInfo')
+      .setTransient(transientData({ password_hash: passwordHash }))
+      .evaluate(networkAddress, tokenID, '');
     console.log('Wallet Info:', JSON.parse(result.toString()));
   } finally {
     gateway.disconnect();
@@ -233,7 +253,9 @@ async function viewPendingCustomerMintRequests(tokenID, ownerNetworkAddress) {
 async function viewCustomerWallet(networkAddress, tokenID, passwordHash) {
   const { contract, gateway } = await getContract(networkAddress);
   try {
-    const result = await contract.evaluateTransaction('ViewCustomerWallet', networkAddress, tokenID, passwordHash);
+    const result = await contract.createTransaction('ViewCustomerWallet')
+      .setTransient(transientData({ password_hash: passwordHash }))
+      .evaluate(networkAddress, tokenID, '');
     console.log('Customer Wallet:', JSON.parse(result.toString()));
   } finally {
     gateway.disconnect();
@@ -313,13 +335,13 @@ async function main() {
         break;
 
       case 'requestTokenRequest':
-        await requestTokenRequest(args[1], args[2], args[3], args[4]);
+        await requestTokenRequest(args[1], args[2], args[3], args[4], args[5]);
         break;
       case 'approveTokenRequest':
         await approveTokenRequest(args[1]);
         break;
       case 'requestMintCoins':
-        await requestMintCoins(args[1], args[2], args[3]);
+        await requestMintCoins(args[1], args[2], args[3], args[4]);
         break;
       case 'approveMintRequest':
         await approveMintRequest(args[1]);
@@ -352,7 +374,7 @@ async function main() {
         await getPendingMintRequests();
         break;
       case 'getWalletInfo':
-        await getWalletInfo(args[1], args[2]);
+        await getWalletInfo(args[1], args[2], args[3]);
         break;
       case 'viewAllTokens':
         await viewAllTokens();
@@ -388,9 +410,9 @@ async function main() {
         console.log('Available commands:');
         console.log(' submitRegistration <name> <passwordHash> <country>');
         console.log(' participantExists <networkAddress>');
-        console.log(' requestTokenRequest <name> <networkAddress> <passwordHash> <country>');
+        console.log(' requestTokenRequest <name> <networkAddress> <passwordHash> <country> [purpose]');
         console.log(' approveTokenRequest <networkAddress>');
-        console.log(' requestMintCoins <networkAddress> <passwordHash> <amount>');
+        console.log(' requestMintCoins <networkAddress> <tokenID> <passwordHash> <amount>');
         console.log(' approveMintRequest <requestID>');
         console.log(' registerCustomer <networkAddress> <name> <passwordHash> <tokenID>');
         console.log(' approveCustomerRegistration <requestID> <ownerNetworkAddress>');
@@ -401,7 +423,7 @@ async function main() {
         console.log(' approveTransferByReceiver <transferRequestID> <approver>');
         console.log(' getPendingTokenRequests');
         console.log(' getPendingMintRequests');
-        console.log(' getWalletInfo <networkAddress> <passwordHash>');
+        console.log(' getWalletInfo <networkAddress> <tokenID> <passwordHash>');
         console.log(' viewAllTokens');
         console.log(' viewPendingCustomerRegistrations <tokenID> <ownerNetworkAddress>');
         console.log(' viewPendingCustomerMintRequests <tokenID> <ownerNetworkAddress>');